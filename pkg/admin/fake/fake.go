@@ -5,82 +5,11 @@ import (
 	"strconv"
 	"time"
 
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
 )
 
-func ListClusterInfo() []apiv1a1.ClusterInfo {
-	count := 5
-	ctrlID := 0
-
-	isCtrl := func(index int) bool { return index == ctrlID }
-	getUUID := func(index int) string { return "uuid-" + strconv.Itoa(index) }
-	getName := func(index int) string {
-		if isCtrl(index) {
-			return "ctrl-" + strconv.Itoa(index)
-		} else {
-			return "user-" + strconv.Itoa(index)
-		}
-	}
-	getAlias := func(index int) string { return "alias-" + getName(index) }
-
-	re := make([]apiv1a1.ClusterInfo, count)
-	for i := range re {
-		ci := &re[i]
-
-		ci.Metadata = apiv1a1.ObjectMetaData{
-			ID:    getUUID(i),
-			Name:  getName(i),
-			Alias: getAlias(i),
-		}
-		ci.Physical = &apiv1a1.Physical{
-			Capacity: corev1.ResourceList{
-				"cpu":    *resource.NewQuantity(int64(i+1)*8, resource.DecimalSI),
-				"memory": *resource.NewQuantity(int64(i+1)*16*1024*1024*1024, resource.BinarySI),
-			},
-			Used: corev1.ResourceList{
-				"cpu":    *resource.NewQuantity(int64(i+1)*3, resource.DecimalSI),
-				"memory": *resource.NewQuantity(int64(i+1)*6*1024*1024*1024, resource.BinarySI),
-			},
-		}
-		ci.Request = apiv1a1.Logical{
-			Capacity: corev1.ResourceList{
-				"cpu":    *resource.NewQuantity(int64(i+1)*24, resource.DecimalSI),
-				"memory": *resource.NewQuantity(int64(i+1)*15*1024*1024*1024, resource.BinarySI),
-			},
-			SystemUsed: corev1.ResourceList{
-				"cpu":    *resource.NewQuantity(int64(i+1)*1, resource.DecimalSI),
-				"memory": *resource.NewQuantity(int64(i+1)*1*1024*1024*1024, resource.BinarySI),
-			},
-			UserUsed: corev1.ResourceList{
-				"cpu":    *resource.NewQuantity(int64(i+1)*2, resource.DecimalSI),
-				"memory": *resource.NewQuantity(int64(i+1)*5*1024*1024*1024, resource.BinarySI),
-			},
-		}
-		ci.Limit = apiv1a1.Logical{
-			Capacity: corev1.ResourceList{
-				"cpu":    *resource.NewQuantity(int64(i+1)*7, resource.DecimalSI),
-				"memory": *resource.NewQuantity(int64(i+1)*15*1024*1024*1024, resource.BinarySI),
-			},
-			SystemUsed: corev1.ResourceList{
-				"cpu":    *resource.NewQuantity(int64(i+1)*1, resource.DecimalSI),
-				"memory": *resource.NewQuantity(int64(i+1)*1*1024*1024*1024, resource.BinarySI),
-			},
-			UserUsed: corev1.ResourceList{
-				"cpu":    *resource.NewQuantity(int64(i+1)*2, resource.DecimalSI),
-				"memory": *resource.NewQuantity(int64(i+1)*5*1024*1024*1024, resource.BinarySI),
-			},
-		}
-		ci.NodeNum = (i + 1) * 3
-		ci.AppNum = (i + 1) * 4
-		ci.PodNum = (i + 1) * 5
-		ci.IsControl = isCtrl(i)
-	}
-	return re
-}
-
 func GetMachineSummary() *apiv1a1.MachineSummary {
 	count := 5
 	masterID := 3
@@ -148,56 +77,6 @@ func GetContinuousIntegrationSummary() *apiv1a1.ContinuousIntegrationSummary {
 	}
 }
 
-func ListRegistryInfo() []apiv1a1.RegistryInfo {
-	count := 5
-
-	re := make([]apiv1a1.RegistryInfo, count)
-	for i := range re {
-		ss := &re[i]
-
-		ss.Name = fmt.Sprintf("registry-%d", count-i)
-		ss.ProjectNum = (i + 1) * 5
-		ss.ImageNum = (i + 1) * (i + 1) * (i + 1)
-		ss.DiskUsage = fmt.Sprintf("%d%d%%", count-i, i)
-	}
-	return re
-}
-
-func ListEvent() []apiv1a1.Event {
-	count := 5
-
-	getType := func(index int) string {
-		switch index % 3 {
-		case 0:
-			return "create"
-		case 1:
-			return "delete"
-		default:
-			return "update"
-		}
-	}
-	getResult := func(index int) string {
-		switch index % 2 {
-		case 0:
-			return "success"
-		default:
-			return "failed"
-		}
-	}
-	re := make([]apiv1a1.Event, count)
-	for i := range re {
-		ss := &re[i]
-
-		ss.Type = getType(i)
-		ss.Result = getResult(i)
-		ss.Time = time.Now().Add(time.Duration(0-i) * time.Minute)
-		ss.User = fmt.Sprintf("uuu-%d", i)
-		ss.Tenant = fmt.Sprintf("ttt-%d", i)
-		ss.Message = fmt.Sprintf("mmm-%d", i)
-	}
-	return re
-}
-
 func GetAddonHealthSummary() *apiv1a1.AddonHealthSummary {
 	return &apiv1a1.AddonHealthSummary{
 		AbnormalNum: 3,
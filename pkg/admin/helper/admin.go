@@ -0,0 +1,50 @@
+package helper
+
+import (
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+	"github.com/caicloud/dashboard-admin/pkg/cache/api"
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+// IsAdmin checks if user is an admin of the platform by cauth, which is an
+// owner in the system tenant. It is false until cauth is synced.
+func IsAdmin(c *cache.Cache, xTenant, xUser string) bool {
+	if xTenant != tntv1al.SystemTenant || len(xUser) == 0 || c.CauthCache.IsStale() {
+		return false
+	}
+	if u, ok := c.CauthCache.GetUsersMap()[xUser]; ok && u.Role == api.OwnerType {
+		return true
+	}
+	t, ok := c.CauthCache.GetTenantsMap()[tntv1al.SystemTenant]
+	if !ok {
+		return false
+	}
+	for _, m := range t.Members {
+		if m.Name == xUser && m.Role == api.OwnerType {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckRefreshCache checks if user may refresh the upstream cache of slug,
+// which is for admins. Admins are unknown until cauth is synced, then any
+// user of the system tenant may refresh cauth to recover it, and the other
+// caches are not refreshed on demand.
+func CheckRefreshCache(c *cache.Cache, xTenant, xUser, slug string) *errors.FormatError {
+	if !c.CauthCache.IsStale() {
+		if !IsAdmin(c, xTenant, xUser) {
+			return errors.NewError().SetErrorForbidden(xTenant, xUser, "refresh caches")
+		}
+		return nil
+	}
+	if xTenant != tntv1al.SystemTenant {
+		return errors.NewError().SetErrorForbidden(xTenant, xUser, "refresh caches")
+	}
+	if slug != api.CacheSlugCauth {
+		return errors.NewError().SetErrorUpstreamNotSynced(api.CacheNameCauth)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package helper
+
+import (
+	"context"
+
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+
+	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+	"github.com/caicloud/dashboard-admin/pkg/cache/crd"
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+// GetAppSummary counts the releases of the cluster by state, system tenant
+// sees all of them and others see their own
+func GetAppSummary(ctx context.Context, c *cache.Cache, xTenant, cluster string) (*apiv1a1.AppSummary, *errors.FormatError) {
+	var (
+		cs *crd.ClusterSummary
+		fe *errors.FormatError
+	)
+	if xTenant == tntv1al.SystemTenant {
+		cs, fe = c.GetClusterSummary(ctx, cluster)
+	} else {
+		cs, fe = c.GetTenantSummary(ctx, cluster, xTenant)
+	}
+	if fe != nil {
+		return nil, fe
+	}
+	return &apiv1a1.AppSummary{
+		NormalNum:   cs.ReleaseStates[crd.ReleaseStateAvailable],
+		UpdatingNum: cs.ReleaseStates[crd.ReleaseStateProgressing] + cs.ReleaseStates[crd.ReleaseStateUnknown],
+		AbnormalNum: cs.ReleaseStates[crd.ReleaseStateFailure],
+	}, nil
+}
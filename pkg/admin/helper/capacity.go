@@ -0,0 +1,109 @@
+package helper
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+	"github.com/caicloud/dashboard-admin/pkg/cache/crd"
+)
+
+// resources with overcommit ratios
+var overcommitResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// ListClusterCapacity returns the capacities of the clusters sorted by name,
+// or of the given cluster only if not empty. It starts no caches, clusters
+// without running caches have the ones of the snapshot or none, as stale.
+func ListClusterCapacity(ctx context.Context, c *cache.Cache, cluster string) []apiv1a1.ClusterCapacity {
+	re := []apiv1a1.ClusterCapacity{}
+	summaries := c.ListClusterSummaries(ctx)
+	for i := range summaries {
+		cs := &summaries[i]
+		if len(cluster) > 0 && cs.Name != cluster {
+			continue
+		}
+		re = append(re, newClusterCapacity(ctx, c, cs))
+	}
+	sort.Slice(re, func(i, j int) bool {
+		return re[i].Cluster < re[j].Cluster
+	})
+	return re
+}
+
+func newClusterCapacity(ctx context.Context, c *cache.Cache, cs *crd.ClusterSummary) apiv1a1.ClusterCapacity {
+	ratios := clusterRatios(ctx, c, cs)
+	re := apiv1a1.ClusterCapacity{
+		Cluster:   cs.Name,
+		Phase:     string(cs.Phase),
+		Stale:     cs.Stale,
+		Resources: make([]apiv1a1.ResourceCapacity, 0, len(overcommitResources)),
+	}
+	for _, name := range overcommitResources {
+		rc := apiv1a1.ResourceCapacity{
+			Name:        name,
+			Ratio:       ratios[name],
+			Capacity:    quantityOf(cs.Capacity, name),
+			Allocatable: quantityOf(cs.Allocatable, name),
+			Requests:    quantityOf(cs.Requests, name),
+			Limits:      quantityOf(cs.Limits, name),
+		}
+		rc.Logical = scaleQuantity(name, rc.Capacity, rc.Ratio)
+		rc.Headroom = rc.Logical.DeepCopy()
+		rc.Headroom.Sub(rc.Requests)
+		rc.Exceeded = rc.Requests.Cmp(rc.Logical) > 0
+		re.Exceeded = re.Exceeded || rc.Exceeded
+		re.Resources = append(re.Resources, rc)
+	}
+	return re
+}
+
+// clusterRatios returns the overcommit ratios of the cluster, the ones of
+// the cluster spec first, then the ones of the system cluster quota, and 1
+// if neither is set
+func clusterRatios(ctx context.Context, c *cache.Cache, cs *crd.ClusterSummary) map[corev1.ResourceName]float64 {
+	re := make(map[corev1.ResourceName]float64, len(overcommitResources))
+	if cl, fe := c.GetCluster(ctx, cs.Name); fe == nil {
+		re[corev1.ResourceCPU] = cl.Spec.Ratio.CpuOverCommitRatio
+		re[corev1.ResourceMemory] = cl.Spec.Ratio.MemoryOverCommitRatio
+	}
+	var quota *tntv1al.ClusterQuota
+	if scc, ok := c.GetStartedSubClusterCaches(cs.Name); ok {
+		if qc, ok := scc.GetClusterQuotaCache(); ok {
+			quota, _ = qc.Get(ctx, tntv1al.SystemClusterQuota)
+		}
+	}
+	for _, name := range overcommitResources {
+		if re[name] > 0 {
+			continue
+		}
+		re[name] = 1
+		if quota != nil && quota.Spec.Ratio[name] > 0 {
+			re[name] = float64(quota.Spec.Ratio[name])
+		}
+	}
+	return re
+}
+
+func quantityOf(rl corev1.ResourceList, name corev1.ResourceName) resource.Quantity {
+	if q, ok := rl[name]; ok {
+		return q.DeepCopy()
+	}
+	if name == corev1.ResourceCPU {
+		return *resource.NewMilliQuantity(0, resource.DecimalSI)
+	}
+	return *resource.NewQuantity(0, resource.BinarySI)
+}
+
+// scaleQuantity returns q * ratio, in millis for cpu
+func scaleQuantity(name corev1.ResourceName, q resource.Quantity, ratio float64) resource.Quantity {
+	if name == corev1.ResourceCPU {
+		return *resource.NewMilliQuantity(int64(math.Round(float64(q.MilliValue())*ratio)), resource.DecimalSI)
+	}
+	return *resource.NewQuantity(int64(math.Round(float64(q.Value())*ratio)), resource.BinarySI)
+}
@@ -0,0 +1,194 @@
+package helper
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+	"github.com/caicloud/dashboard-admin/pkg/cache/api"
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+// ListRegistryInfo returns the registries sorted by name with the projects,
+// images and storage xTenant can see in them
+func ListRegistryInfo(c *cache.Cache, xTenant string) ([]apiv1a1.RegistryInfo, *errors.FormatError) {
+	if !c.CargoCache.IsLoaded() {
+		return nil, errors.NewError().SetErrorUpstreamNotSynced(api.CacheNameCargo)
+	}
+	re := []apiv1a1.RegistryInfo{}
+	for name := range c.CargoCache.GetRegistriesMap() {
+		var size int64
+		ri := apiv1a1.RegistryInfo{Name: name}
+		for _, pd := range c.CargoCache.GetProjectsMap(name) {
+			if xTenant != tntv1al.SystemTenant && !hasTenant(pd.Tenants, xTenant) {
+				continue
+			}
+			ri.ProjectNum++
+			ri.ImageNum += len(pd.Repositories)
+			for _, r := range pd.Repositories {
+				if r.Status != nil {
+					size += r.Status.Size
+				}
+			}
+		}
+		ri.DiskUsage = *resource.NewQuantity(size, resource.BinarySI)
+		re = append(re, ri)
+	}
+	sort.Slice(re, func(i, j int) bool {
+		return re[i].Name < re[j].Name
+	})
+	return re, nil
+}
+
+// ListProjectInfo returns the projects of registry sorted by name, system
+// tenant sees all of them and others see the ones of their own
+func ListProjectInfo(c *cache.Cache, xTenant, registry string) ([]apiv1a1.ProjectInfo, *errors.FormatError) {
+	if !c.CargoCache.IsLoaded() {
+		return nil, errors.NewError().SetErrorUpstreamNotSynced(api.CacheNameCargo)
+	}
+	if _, ok := c.CargoCache.GetRegistriesMap()[registry]; !ok {
+		return nil, errors.NewError().SetErrorObjectNotFound(registry, fmt.Errorf("registry %s not found", registry))
+	}
+	re := []apiv1a1.ProjectInfo{}
+	for _, pd := range c.CargoCache.GetProjectsMap(registry) {
+		if xTenant != tntv1al.SystemTenant && !hasTenant(pd.Tenants, xTenant) {
+			continue
+		}
+		re = append(re, newProjectInfo(xTenant, pd))
+	}
+	sort.Slice(re, func(i, j int) bool {
+		return re[i].Name < re[j].Name
+	})
+	return re, nil
+}
+
+func newProjectInfo(xTenant string, pd *api.ProjectDetail) apiv1a1.ProjectInfo {
+	var size int64
+	re := apiv1a1.ProjectInfo{
+		Name:         pd.Project.Metadata.Name,
+		Registry:     pd.Registry,
+		CreationTime: pd.Project.Metadata.CreationTime,
+		ImageNum:     len(pd.Repositories),
+		Stale:        pd.Stale,
+	}
+	if pd.Project.Spec != nil {
+		re.Description = pd.Project.Spec.Description
+		re.IsPublic = pd.Project.Spec.IsPublic
+	}
+	if xTenant == tntv1al.SystemTenant {
+		re.Tenants = pd.Tenants
+	}
+	for _, r := range pd.Repositories {
+		if r.Status == nil {
+			continue
+		}
+		re.TagNum += r.Status.TagCount
+		re.PullNum += r.Status.PullCount
+		size += r.Status.Size
+	}
+	re.StorageUsage = *resource.NewQuantity(size, resource.BinarySI)
+	return re
+}
+
+// ListWorkspaceCargo joins the workspaces xTenant can see with the cargo
+// projects they push to, the registry is matched by name and then by host.
+// It fails before cargo is loaded rather than flag every registry not found.
+func ListWorkspaceCargo(c *cache.Cache, xTenant string) (*apiv1a1.WorkspaceCargoList, *errors.FormatError) {
+	if !c.CargoCache.IsLoaded() {
+		return nil, errors.NewError().SetErrorUpstreamNotSynced(api.CacheNameCargo)
+	}
+	registries := c.CargoCache.GetRegistriesMap()
+	re := &apiv1a1.WorkspaceCargoList{Items: []apiv1a1.WorkspaceCargo{}}
+	for _, wd := range listWorkspaceDetails(c, xTenant) {
+		wc := apiv1a1.WorkspaceCargo{
+			Tenant:    wd.Tenant,
+			Workspace: wd.Workspace.Name,
+			Status:    apiv1a1.WorkspaceCargoNotConfigured,
+			Images:    []apiv1a1.CargoImage{},
+		}
+		if cargo := wd.Workspace.Cargo; cargo != nil && (len(cargo.Name) > 0 || len(cargo.Host) > 0) {
+			wc.Registry, wc.Project, wc.Host = cargo.Name, cargo.Project, cargo.Host
+			registry := findRegistry(registries, cargo)
+			if registry == nil {
+				wc.Status = apiv1a1.WorkspaceCargoRegistryNotFound
+				re.RegistryNotFoundNum++
+			} else if pd := c.CargoCache.GetProjectsMap(registry.Metadata.Name)[cargo.Project]; pd == nil {
+				wc.Registry = registry.Metadata.Name
+				wc.Status = apiv1a1.WorkspaceCargoProjectNotFound
+				re.ProjectNotFoundNum++
+			} else {
+				wc.Registry = registry.Metadata.Name
+				wc.Status = apiv1a1.WorkspaceCargoLinked
+				wc.Images = newCargoImages(pd)
+			}
+		}
+		re.Items = append(re.Items, wc)
+	}
+	return re, nil
+}
+
+// findRegistry returns the registry named by cargo, or the one whose host or
+// domain is the cargo host
+func findRegistry(registries map[string]*api.Registry, cargo *api.Cargo) *api.Registry {
+	if r := registries[cargo.Name]; r != nil {
+		return r
+	}
+	host := trimHost(cargo.Host)
+	if len(host) == 0 {
+		return nil
+	}
+	for _, r := range registries {
+		if r.Spec != nil && (trimHost(r.Spec.Host) == host || trimHost(r.Spec.Domain) == host) {
+			return r
+		}
+	}
+	return nil
+}
+
+// trimHost strips the scheme and path of a registry host
+func trimHost(host string) string {
+	if strings.Contains(host, "://") {
+		if u, e := url.Parse(host); e == nil {
+			return u.Host
+		}
+	}
+	return strings.TrimSuffix(host, "/")
+}
+
+func newCargoImages(pd *api.ProjectDetail) []apiv1a1.CargoImage {
+	re := make([]apiv1a1.CargoImage, 0, len(pd.Repositories))
+	for _, r := range pd.Repositories {
+		if r.Metadata == nil {
+			continue
+		}
+		ci := apiv1a1.CargoImage{
+			Name:           r.Metadata.Name,
+			LastUpdateTime: r.Metadata.LastUpdateTime,
+		}
+		var size int64
+		if r.Status != nil {
+			ci.TagNum, ci.PullNum, size = r.Status.TagCount, r.Status.PullCount, r.Status.Size
+		}
+		ci.Size = *resource.NewQuantity(size, resource.BinarySI)
+		re = append(re, ci)
+	}
+	sort.Slice(re, func(i, j int) bool {
+		return re[i].Name < re[j].Name
+	})
+	return re
+}
+
+func hasTenant(tenants []string, tenant string) bool {
+	for _, t := range tenants {
+		if t == tenant {
+			return true
+		}
+	}
+	return false
+}
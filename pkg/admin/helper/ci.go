@@ -0,0 +1,236 @@
+package helper
+
+import (
+	"math"
+	"sort"
+
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+
+	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+	"github.com/caicloud/dashboard-admin/pkg/cache/api"
+)
+
+// status of cyclone pipeline records
+const (
+	PipelineRecordPending = "Pending"
+	PipelineRecordRunning = "Running"
+	PipelineRecordSuccess = "Success"
+	PipelineRecordFailed  = "Failed"
+	PipelineRecordAborted = "Aborted"
+)
+
+const (
+	// recent failures in a pipeline stats
+	recentFailureNum = 5
+)
+
+// GetContinuousIntegrationSummary counts the workspaces and pipelines and the
+// records of them, system tenant sees all tenants and others see their own
+func GetContinuousIntegrationSummary(c *cache.Cache, xTenant string) *apiv1a1.ContinuousIntegrationSummary {
+	type tenantStats struct {
+		apiv1a1.TenantPipelineStats
+		b pipelineStatsBuilder
+	}
+	var (
+		all     pipelineStatsBuilder
+		tenants = make(map[string]*tenantStats)
+		re      = &apiv1a1.ContinuousIntegrationSummary{Workspaces: []apiv1a1.WorkspacePipelineStats{}}
+	)
+	for _, wd := range listWorkspaceDetails(c, xTenant) {
+		ts, ok := tenants[wd.Tenant]
+		if !ok {
+			ts = &tenantStats{TenantPipelineStats: apiv1a1.TenantPipelineStats{Tenant: wd.Tenant}}
+			tenants[wd.Tenant] = ts
+		}
+		var ws pipelineStatsBuilder
+		for i := range wd.Pipelines {
+			p := &wd.Pipelines[i]
+			records := mergePipelineRecords(p)
+			for _, b := range []*pipelineStatsBuilder{&all, &ts.b, &ws} {
+				b.add(wd.Tenant, wd.Workspace.Name, p, records)
+			}
+		}
+		re.WorkspaceNum++
+		re.PipelineNum += len(wd.Pipelines)
+		ts.WorkspaceNum++
+		ts.PipelineNum += len(wd.Pipelines)
+		re.Workspaces = append(re.Workspaces, apiv1a1.WorkspacePipelineStats{
+			Tenant:        wd.Tenant,
+			Workspace:     wd.Workspace.Name,
+			PipelineNum:   len(wd.Pipelines),
+			PipelineStats: ws.build(),
+		})
+	}
+	re.PipelineStats = all.build()
+	if xTenant == tntv1al.SystemTenant {
+		for _, ts := range tenants {
+			ts.PipelineStats = ts.b.build()
+			re.Tenants = append(re.Tenants, ts.TenantPipelineStats)
+		}
+		sort.Slice(re.Tenants, func(i, j int) bool {
+			return re.Tenants[i].Tenant < re.Tenants[j].Tenant
+		})
+	}
+	return re
+}
+
+// ListFailingPipelines returns the pipelines whose last finished record failed,
+// the latest failure first
+func ListFailingPipelines(c *cache.Cache, xTenant string) []apiv1a1.FailingPipeline {
+	var re []apiv1a1.FailingPipeline
+	for _, wd := range listWorkspaceDetails(c, xTenant) {
+		for i := range wd.Pipelines {
+			p := &wd.Pipelines[i]
+			records := mergePipelineRecords(p)
+			var last *api.PipelineRecord
+			failedNum := 0
+			for j := range records {
+				r := &records[j]
+				switch r.Status {
+				case PipelineRecordFailed:
+					failedNum++
+				case PipelineRecordSuccess:
+				default:
+					continue
+				}
+				if last == nil || r.StartTime.After(last.StartTime) {
+					last = r
+				}
+			}
+			if last == nil || last.Status != PipelineRecordFailed {
+				continue
+			}
+			re = append(re, apiv1a1.FailingPipeline{
+				Tenant:      wd.Tenant,
+				Workspace:   wd.Workspace.Name,
+				Pipeline:    p.Name,
+				Alias:       p.Alias,
+				Owner:       p.Owner,
+				FailedNum:   failedNum,
+				LastFailure: newPipelineFailure(wd.Tenant, wd.Workspace.Name, p, last),
+			})
+		}
+	}
+	sort.Slice(re, func(i, j int) bool {
+		ti, tj := re[i].LastFailure.StartTime, re[j].LastFailure.StartTime
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return api.WorkspaceKey(re[i].Tenant, re[i].Workspace)+"/"+re[i].Pipeline <
+			api.WorkspaceKey(re[j].Tenant, re[j].Workspace)+"/"+re[j].Pipeline
+	})
+	return re
+}
+
+// listWorkspaceDetails returns the workspaces xTenant can see sorted by key
+func listWorkspaceDetails(c *cache.Cache, xTenant string) []*api.WorkspaceDetail {
+	wsMap := c.DevopCache.GetWorkspaceMap()
+	keys := make([]string, 0, len(wsMap))
+	for k, wd := range wsMap {
+		if wd.Workspace == nil {
+			continue
+		}
+		if xTenant != tntv1al.SystemTenant && wd.Tenant != xTenant {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	re := make([]*api.WorkspaceDetail, 0, len(keys))
+	for _, k := range keys {
+		re = append(re, wsMap[k])
+	}
+	return re
+}
+
+// mergePipelineRecords returns the recent, success and failed records of p
+// without duplicates
+func mergePipelineRecords(p *api.Pipeline) []api.PipelineRecord {
+	seen := make(map[string]bool)
+	var re []api.PipelineRecord
+	for _, records := range [][]api.PipelineRecord{p.RecentRecords, p.RecentSuccessRecords, p.RecentFailedRecords} {
+		for _, r := range records {
+			id := r.ID
+			if len(id) == 0 {
+				id = r.Name
+			}
+			if len(id) > 0 {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+			}
+			re = append(re, r)
+		}
+	}
+	return re
+}
+
+func newPipelineFailure(tenant, workspace string, p *api.Pipeline, r *api.PipelineRecord) apiv1a1.PipelineFailure {
+	return apiv1a1.PipelineFailure{
+		Tenant:       tenant,
+		Workspace:    workspace,
+		Pipeline:     p.Name,
+		RecordID:     r.ID,
+		RecordName:   r.Name,
+		ErrorMessage: r.ErrorMessage,
+		StartTime:    r.StartTime,
+		EndTime:      r.EndTime,
+	}
+}
+
+type pipelineStatsBuilder struct {
+	stats     apiv1a1.PipelineStats
+	durations []float64
+	failures  []apiv1a1.PipelineFailure
+}
+
+// add counts the records of p merged by mergePipelineRecords
+func (b *pipelineStatsBuilder) add(tenant, workspace string, p *api.Pipeline, records []api.PipelineRecord) {
+	for _, r := range records {
+		b.stats.RecordNum++
+		switch r.Status {
+		case PipelineRecordRunning:
+			b.stats.RunningNum++
+			continue
+		case PipelineRecordSuccess:
+			b.stats.SuccessNum++
+		case PipelineRecordFailed:
+			b.stats.FailedNum++
+			b.failures = append(b.failures, newPipelineFailure(tenant, workspace, p, &r))
+		default:
+			continue
+		}
+		if !r.StartTime.IsZero() && r.EndTime.After(r.StartTime) {
+			b.durations = append(b.durations, r.EndTime.Sub(r.StartTime).Seconds())
+		}
+	}
+}
+
+func (b *pipelineStatsBuilder) build() apiv1a1.PipelineStats {
+	re := b.stats
+	if finished := re.SuccessNum + re.FailedNum; finished > 0 {
+		re.SuccessRate = float64(re.SuccessNum) / float64(finished)
+	}
+	if n := len(b.durations); n > 0 {
+		sort.Float64s(b.durations)
+		sum := 0.0
+		for _, d := range b.durations {
+			sum += d
+		}
+		re.AvgDuration = sum / float64(n)
+		re.P95Duration = b.durations[int(math.Ceil(0.95*float64(n)))-1]
+	}
+	sort.Slice(b.failures, func(i, j int) bool {
+		return b.failures[i].StartTime.After(b.failures[j].StartTime)
+	})
+	re.RecentFailures = b.failures
+	if len(re.RecentFailures) > recentFailureNum {
+		re.RecentFailures = re.RecentFailures[:recentFailureNum]
+	}
+	if re.RecentFailures == nil {
+		re.RecentFailures = []apiv1a1.PipelineFailure{}
+	}
+	return re
+}
@@ -0,0 +1,85 @@
+package helper
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/caicloud/dashboard-admin/pkg/cache/api"
+)
+
+// newTestRecord starts at minute i and takes seconds
+func newTestRecord(id, status string, i int, seconds int) api.PipelineRecord {
+	start := time.Date(2020, 1, 1, 0, i, 0, 0, time.UTC)
+	return api.PipelineRecord{
+		ID:        id,
+		Status:    status,
+		StartTime: start,
+		EndTime:   start.Add(time.Duration(seconds) * time.Second),
+	}
+}
+
+func TestMergePipelineRecords(t *testing.T) {
+	a := newTestRecord("a", PipelineRecordSuccess, 0, 1)
+	b := newTestRecord("b", PipelineRecordFailed, 1, 1)
+	p := &api.Pipeline{
+		RecentRecords:        []api.PipelineRecord{a, b},
+		RecentSuccessRecords: []api.PipelineRecord{a},
+		RecentFailedRecords:  []api.PipelineRecord{b, newTestRecord("", PipelineRecordFailed, 2, 1)},
+	}
+	records := mergePipelineRecords(p)
+	if len(records) != 3 || records[0].ID != "a" || records[1].ID != "b" || records[2].ID != "" {
+		t.Fatalf("expect a, b and the one without id, got %+v", records)
+	}
+}
+
+func TestPipelineStats(t *testing.T) {
+	var records []api.PipelineRecord
+	// 20 finished ones taking 1..20 seconds, the 4th of every 4 failed
+	for i := 1; i <= 20; i++ {
+		status := PipelineRecordSuccess
+		if i%4 == 0 {
+			status = PipelineRecordFailed
+		}
+		records = append(records, newTestRecord(fmt.Sprint(i), status, i, i))
+	}
+	records = append(records,
+		newTestRecord("running", PipelineRecordRunning, 30, 0),
+		newTestRecord("aborted", PipelineRecordAborted, 31, 100),
+	)
+	var b pipelineStatsBuilder
+	b.add("t", "w", &api.Pipeline{Name: "p"}, records)
+	stats := b.build()
+
+	if stats.RecordNum != 22 || stats.SuccessNum != 15 || stats.FailedNum != 5 || stats.RunningNum != 1 {
+		t.Fatalf("unexpected counts %+v", stats)
+	}
+	if stats.SuccessRate != 0.75 {
+		t.Fatalf("expect success rate 0.75, got %v", stats.SuccessRate)
+	}
+	if stats.AvgDuration != 10.5 {
+		t.Fatalf("expect avg duration 10.5, got %v", stats.AvgDuration)
+	}
+	// the 19th of 20
+	if stats.P95Duration != 19 {
+		t.Fatalf("expect p95 duration 19, got %v", stats.P95Duration)
+	}
+	if len(stats.RecentFailures) != recentFailureNum || stats.RecentFailures[0].RecordID != "20" {
+		t.Fatalf("expect the latest %d failures from 20, got %+v", recentFailureNum, stats.RecentFailures)
+	}
+}
+
+func TestPipelineStatsEmpty(t *testing.T) {
+	var b pipelineStatsBuilder
+	b.add("t", "w", &api.Pipeline{Name: "p"}, []api.PipelineRecord{newTestRecord("r", PipelineRecordRunning, 0, 0)})
+	stats := b.build()
+	if stats.SuccessRate != 0 || stats.P95Duration != 0 || stats.AvgDuration != 0 || stats.RecentFailures == nil {
+		t.Fatalf("expect zero rates and empty failures without finished records, got %+v", stats)
+	}
+	// a single record is its own p95
+	b = pipelineStatsBuilder{}
+	b.add("t", "w", &api.Pipeline{Name: "p"}, []api.PipelineRecord{newTestRecord("r", PipelineRecordFailed, 0, 7)})
+	if stats = b.build(); stats.P95Duration != 7 || stats.SuccessRate != 0 {
+		t.Fatalf("expect p95 7 and success rate 0, got %+v", stats)
+	}
+}
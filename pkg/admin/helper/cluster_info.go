@@ -1,10 +1,169 @@
 package helper
 
 import (
+	"context"
+	"sort"
+	"time"
+
+	resv1b1 "github.com/caicloud/clientset/pkg/apis/resource/v1beta1"
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+
 	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
 	"github.com/caicloud/dashboard-admin/pkg/cache"
+	"github.com/caicloud/dashboard-admin/pkg/cache/crd"
+	"github.com/caicloud/dashboard-admin/pkg/errors"
 )
 
-func ListClusterInfo(c *cache.Cache, xTenant string) ([]apiv1a1.ClusterInfo, error) {
-	return nil, nil
+// ListClusterInfo returns the clusters sorted by name with the overcommitted
+// capacities and the usage of system and users, system tenant sees the
+// physical capacities and the headroom as well. It starts no caches, clusters
+// without running caches have the numbers of the snapshot or none, as stale.
+func ListClusterInfo(ctx context.Context, c *cache.Cache, xTenant string) []apiv1a1.ClusterInfo {
+	summaries := c.ListClusterSummaries(ctx)
+	re := make([]apiv1a1.ClusterInfo, 0, len(summaries))
+	for i := range summaries {
+		re = append(re, newClusterInfo(ctx, c, xTenant, &summaries[i]))
+	}
+	sort.Slice(re, func(i, j int) bool {
+		return re[i].Metadata.Name < re[j].Metadata.Name
+	})
+	return re
+}
+
+func newClusterInfo(ctx context.Context, c *cache.Cache, xTenant string, cs *crd.ClusterSummary) apiv1a1.ClusterInfo {
+	capacity := newClusterCapacity(ctx, c, cs)
+	re := apiv1a1.ClusterInfo{
+		Metadata: apiv1a1.ObjectMetaData{Name: cs.Name},
+		NodeNum:  cs.NodeNum,
+		AppNum:   cs.ReleaseNum,
+		PodNum:   cs.PodNum,
+		Stale:    cs.Stale,
+	}
+	// of the namespaces owned by tenants, the rest is used by system
+	userRequests, userLimits := corev1.ResourceList{}, corev1.ResourceList{}
+	if cl, fe := c.GetCluster(ctx, cs.Name); fe == nil {
+		re.Metadata.ID = string(cl.UID)
+		re.Metadata.CreationTime = cl.CreationTimestamp.Format(time.RFC3339)
+		re.Metadata.Alias = cl.Spec.DisplayName
+		re.IsControl = cl.Spec.IsControlCluster
+		if scc, ok := c.GetStartedSubClusterCaches(cs.Name); ok {
+			for tenant, ts := range scc.TenantSummaries(cl) {
+				if len(tenant) > 0 && tenant != tntv1al.SystemTenant {
+					crd.AddResourceList(userRequests, ts.Requests)
+					crd.AddResourceList(userLimits, ts.Limits)
+				}
+			}
+		}
+	}
+	logical := make(corev1.ResourceList, len(capacity.Resources))
+	for _, rc := range capacity.Resources {
+		logical[rc.Name] = rc.Logical
+	}
+	re.Request = newLogical(logical, cs.Requests, userRequests)
+	re.Limit = newLogical(logical, cs.Limits, userLimits)
+	if xTenant == tntv1al.SystemTenant {
+		re.Physical = &apiv1a1.Physical{
+			Capacity: cs.Capacity.DeepCopy(),
+			Used:     cs.Requests.DeepCopy(),
+		}
+		re.Capacity = &capacity
+	}
+	return re
+}
+
+// newLogical splits used of the cluster into the ones of users and system
+func newLogical(capacity, used, userUsed corev1.ResourceList) apiv1a1.Logical {
+	systemUsed := used.DeepCopy()
+	if systemUsed == nil {
+		systemUsed = corev1.ResourceList{}
+	}
+	for name, q := range userUsed {
+		if s, ok := systemUsed[name]; ok {
+			s.Sub(q)
+			systemUsed[name] = s
+		}
+	}
+	return apiv1a1.Logical{
+		Capacity:   capacity.DeepCopy(),
+		SystemUsed: systemUsed,
+		UserUsed:   userUsed,
+	}
+}
+
+// GetClusterDetail returns the cluster with the sync states of its caches,
+// the auth of the cluster is never copied
+func GetClusterDetail(ctx context.Context, c *cache.Cache, cluster string) (*apiv1a1.ClusterDetail, *errors.FormatError) {
+	cl, fe := c.GetCluster(ctx, cluster)
+	if fe != nil {
+		return nil, fe
+	}
+	re := &apiv1a1.ClusterDetail{
+		Metadata: apiv1a1.ObjectMetaData{
+			ID:           string(cl.UID),
+			Name:         cl.Name,
+			CreationTime: cl.CreationTimestamp.Format(time.RFC3339),
+			Alias:        cl.Spec.DisplayName,
+		},
+		DisplayName:      cl.Spec.DisplayName,
+		Provider:         string(cl.Spec.Provider),
+		IsControlCluster: cl.Spec.IsControlCluster,
+		IsHighAvailable:  cl.Spec.IsHighAvailable,
+		Network:          apiv1a1.ClusterNetwork{Type: string(cl.Spec.Network.Type)},
+		Ratio: apiv1a1.ClusterRatio{
+			CPUOverCommitRatio:    cl.Spec.Ratio.CpuOverCommitRatio,
+			MemoryOverCommitRatio: cl.Spec.Ratio.MemoryOverCommitRatio,
+		},
+		Phase:      string(cl.Status.Phase),
+		Conditions: make([]apiv1a1.ClusterCondition, 0, len(cl.Status.Conditions)),
+		Masters:    newMachineThumbnails(cl.Status.Masters),
+		Nodes:      newMachineThumbnails(cl.Status.Nodes),
+	}
+	if v := cl.Spec.Versions; v != nil {
+		re.Versions = &apiv1a1.ClusterVersions{
+			MasterSets: copyStringMap(v.MasterSets),
+			NodeSets:   copyStringMap(v.NodeSets),
+		}
+	}
+	for _, cond := range cl.Status.Conditions {
+		re.Conditions = append(re.Conditions, apiv1a1.ClusterCondition{
+			Type:               string(cond.Type),
+			Status:             string(cond.Status),
+			LastHeartbeatTime:  cond.LastHeartbeatTime.Time,
+			LastTransitionTime: cond.LastTransitionTime.Time,
+			Reason:             cond.Reason,
+			Message:            cond.Message,
+		})
+		if cond.LastTransitionTime.Time.After(re.LastTransitionTime) {
+			re.LastTransitionTime = cond.LastTransitionTime.Time
+		}
+	}
+	if states := c.GetSubCacheStates(cluster); states != nil {
+		re.CacheSynced = true
+		re.Caches = make([]apiv1a1.ClusterCacheState, 0, len(states))
+		for _, s := range states {
+			re.Caches = append(re.Caches, apiv1a1.ClusterCacheState{Name: s.Name, Synced: s.Synced})
+			re.CacheSynced = re.CacheSynced && s.Synced
+		}
+	}
+	return re, nil
+}
+
+func newMachineThumbnails(machines []resv1b1.MachineThumbnail) []apiv1a1.MachineThumbnail {
+	re := make([]apiv1a1.MachineThumbnail, 0, len(machines))
+	for _, m := range machines {
+		re = append(re, apiv1a1.MachineThumbnail{Name: m.Name, Status: string(m.Status)})
+	}
+	return re
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	re := make(map[string]string, len(m))
+	for k, v := range m {
+		re[k] = v
+	}
+	return re
 }
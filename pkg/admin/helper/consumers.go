@@ -0,0 +1,202 @@
+package helper
+
+import (
+	"context"
+	"sort"
+
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+	"github.com/caicloud/nirvana/log"
+	corev1 "k8s.io/api/core/v1"
+
+	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+	"github.com/caicloud/dashboard-admin/pkg/cache/crd"
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+const (
+	// label of the pods created by a release
+	labelRelease = "controller.caicloud.io/release"
+
+	DefaultTopConsumerNum = 10
+	MaxTopConsumerNum     = 100
+)
+
+// GetTopConsumers returns the top n namespaces, partitions and releases by
+// sortBy in the cluster, or in all the clusters with running caches if
+// cluster is empty. System tenant sees all of them and others see the ones
+// of their own.
+func GetTopConsumers(ctx context.Context, c *cache.Cache, xTenant, cluster, sortBy string, n int) (*apiv1a1.TopConsumers, *errors.FormatError) {
+	if len(sortBy) == 0 {
+		sortBy = apiv1a1.ConsumerSortCPURequests
+	}
+	if n == 0 {
+		n = DefaultTopConsumerNum
+	}
+	var b consumersBuilder
+	if len(cluster) > 0 {
+		scc, fe := c.GetSubClusterCaches(ctx, cluster)
+		if fe != nil {
+			return nil, fe
+		}
+		if fe = b.addCluster(ctx, scc, xTenant, cluster); fe != nil {
+			return nil, fe
+		}
+	} else {
+		// only the clusters whose caches are running, they are never started
+		// for all the clusters
+		for _, cs := range c.ListClusterSummaries(ctx) {
+			scc, ok := c.GetStartedSubClusterCaches(cs.Name)
+			if !ok {
+				continue
+			}
+			if fe := b.addCluster(ctx, scc, xTenant, cs.Name); fe != nil {
+				log.Warningf("GetTopConsumers skip cluster %s, %v", cs.Name, fe.Error())
+			}
+		}
+	}
+	return &apiv1a1.TopConsumers{
+		SortBy:     sortBy,
+		Namespaces: topConsumers(b.namespaces, sortBy, n),
+		Partitions: topConsumers(b.partitions, sortBy, n),
+		Releases:   topConsumers(b.releases, sortBy, n),
+	}, nil
+}
+
+// consumerCaches are the caches of a cluster read for the consumers
+type consumerCaches interface {
+	GetPodCache() (*crd.PodsCache, bool)
+	GetReleaseCache() (*crd.ReleasesCache, bool)
+	TenantOfNamespace(namespace string) string
+}
+
+// consumersBuilder keeps the partitions as the same consumers of the
+// namespaces owned by tenants
+type consumersBuilder struct {
+	namespaces, partitions, releases []*apiv1a1.ResourceConsumer
+}
+
+func (b *consumersBuilder) addCluster(ctx context.Context, scc consumerCaches, xTenant, cluster string) *errors.FormatError {
+	pc, ok := scc.GetPodCache()
+	if !ok {
+		return errors.NewError().SetErrorCacheDisabled(cluster, crd.CacheNamePod)
+	}
+	// namespace/name of the releases
+	releases := make(map[string]bool)
+	if rc, ok := scc.GetReleaseCache(); ok {
+		for _, r := range rc.ListCachePointer(ctx, "") {
+			releases[crd.NamespaceKey(r.Namespace, r.Name)] = true
+		}
+	}
+	var (
+		namespaces  = make(map[string]*apiv1a1.ResourceConsumer)
+		releaseMap  = make(map[string]*apiv1a1.ResourceConsumer)
+		tenantCache = make(map[string]string)
+	)
+	for _, pod := range pc.ListCachePointer(ctx, "") {
+		if pod.IsTerminated() {
+			continue
+		}
+		tenant, ok := tenantCache[pod.Namespace]
+		if !ok {
+			tenant = scc.TenantOfNamespace(pod.Namespace)
+			tenantCache[pod.Namespace] = tenant
+		}
+		if xTenant != tntv1al.SystemTenant && tenant != xTenant {
+			continue
+		}
+		requests, limits := pod.ResourceRequests(), pod.ResourceLimits()
+		ns, ok := namespaces[pod.Namespace]
+		if !ok {
+			ns = newResourceConsumer(cluster, pod.Namespace, pod.Namespace, tenant)
+			namespaces[pod.Namespace] = ns
+		}
+		addConsumerPod(ns, requests, limits)
+		release := pod.Labels[labelRelease]
+		key := crd.NamespaceKey(pod.Namespace, release)
+		if len(release) == 0 || !releases[key] {
+			continue
+		}
+		rc, ok := releaseMap[key]
+		if !ok {
+			rc = newResourceConsumer(cluster, pod.Namespace, release, tenant)
+			releaseMap[key] = rc
+		}
+		addConsumerPod(rc, requests, limits)
+	}
+	for _, ns := range namespaces {
+		b.namespaces = append(b.namespaces, ns)
+		// a partition is the namespace of the same name owned by a tenant
+		if len(ns.Tenant) > 0 {
+			b.partitions = append(b.partitions, ns)
+		}
+	}
+	for _, rc := range releaseMap {
+		b.releases = append(b.releases, rc)
+	}
+	return nil
+}
+
+func newResourceConsumer(cluster, namespace, name, tenant string) *apiv1a1.ResourceConsumer {
+	return &apiv1a1.ResourceConsumer{
+		Cluster:   cluster,
+		Namespace: namespace,
+		Name:      name,
+		Tenant:    tenant,
+		Requests:  corev1.ResourceList{},
+		Limits:    corev1.ResourceList{},
+	}
+}
+
+func addConsumerPod(rc *apiv1a1.ResourceConsumer, requests, limits corev1.ResourceList) {
+	rc.PodNum++
+	crd.AddResourceList(rc.Requests, requests)
+	crd.AddResourceList(rc.Limits, limits)
+}
+
+// topConsumers sorts consumers by sortBy and returns the first n of them
+func topConsumers(consumers []*apiv1a1.ResourceConsumer, sortBy string, n int) []apiv1a1.ResourceConsumer {
+	key := func(rc *apiv1a1.ResourceConsumer) int64 {
+		switch sortBy {
+		case apiv1a1.ConsumerSortMemoryRequests:
+			return quantityValue(rc.Requests, corev1.ResourceMemory)
+		case apiv1a1.ConsumerSortCPULimits:
+			return quantityValue(rc.Limits, corev1.ResourceCPU)
+		case apiv1a1.ConsumerSortMemoryLimits:
+			return quantityValue(rc.Limits, corev1.ResourceMemory)
+		case apiv1a1.ConsumerSortPods:
+			return int64(rc.PodNum)
+		}
+		return quantityValue(rc.Requests, corev1.ResourceCPU)
+	}
+	sort.Slice(consumers, func(i, j int) bool {
+		if ki, kj := key(consumers[i]), key(consumers[j]); ki != kj {
+			return ki > kj
+		}
+		ci, cj := consumers[i], consumers[j]
+		if ci.Cluster != cj.Cluster {
+			return ci.Cluster < cj.Cluster
+		}
+		return crd.NamespaceKey(ci.Namespace, ci.Name) < crd.NamespaceKey(cj.Namespace, cj.Name)
+	})
+	if len(consumers) > n {
+		consumers = consumers[:n]
+	}
+	re := make([]apiv1a1.ResourceConsumer, 0, len(consumers))
+	for _, rc := range consumers {
+		re = append(re, *rc)
+	}
+	return re
+}
+
+// quantityValue is in millis for cpu
+func quantityValue(rl corev1.ResourceList, name corev1.ResourceName) int64 {
+	q, ok := rl[name]
+	if !ok {
+		return 0
+	}
+	if name == corev1.ResourceCPU {
+		return q.MilliValue()
+	}
+	return q.Value()
+}
@@ -0,0 +1,37 @@
+package helper
+
+import (
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+
+	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+)
+
+const (
+	eventResultSuccess = "success"
+	eventResultFailed  = "failed"
+)
+
+// ListEvents returns the latest app events of all clusters, the latest first,
+// system tenant sees all of them and others see their own
+func ListEvents(c *cache.Cache, xTenant string) []apiv1a1.Event {
+	re := []apiv1a1.Event{}
+	for _, ev := range c.ListAppEvents() {
+		if xTenant != tntv1al.SystemTenant && ev.Tenant != xTenant {
+			continue
+		}
+		result := eventResultSuccess
+		if ev.Failed {
+			result = eventResultFailed
+		}
+		re = append(re, apiv1a1.Event{
+			Type:    ev.Type,
+			Result:  result,
+			Time:    ev.Time,
+			Tenant:  ev.Tenant,
+			Cluster: ev.Cluster,
+			Message: ev.Message,
+		})
+	}
+	return re
+}
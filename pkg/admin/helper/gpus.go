@@ -0,0 +1,135 @@
+package helper
+
+import (
+	"context"
+	"sort"
+
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+
+	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+	"github.com/caicloud/dashboard-admin/pkg/cache/crd"
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+// deprecated name of nvidia gpus, still counted
+const resourceAlphaNvidiaGPU corev1.ResourceName = "alpha.kubernetes.io/nvidia-gpu"
+
+// GetGPUSummary sums the gpus of the nodes of the cluster and the gpus
+// requested by pods on them and by tenants, system tenant sees the nodes
+// and all tenants, others see the free gpus and their own tenant only, as
+// nodes carry the requests of all tenants
+func GetGPUSummary(ctx context.Context, c *cache.Cache, xTenant, cluster string) (*apiv1a1.GPUSummary, *errors.FormatError) {
+	scc, fe := c.GetSubClusterCaches(ctx, cluster)
+	if fe != nil {
+		return nil, fe
+	}
+	nc, ok := scc.GetNodeCache()
+	if !ok {
+		return nil, errors.NewError().SetErrorCacheDisabled(cluster, crd.CacheNameNode)
+	}
+	pc, ok := scc.GetPodCache()
+	if !ok {
+		return nil, errors.NewError().SetErrorCacheDisabled(cluster, crd.CacheNamePod)
+	}
+
+	nodes := make(map[string]*apiv1a1.NodeGPU)
+	for _, node := range nc.ListCachePointer(ctx) {
+		capacity := gpuNum(node.Capacity)
+		if capacity == 0 {
+			continue
+		}
+		nodes[node.Name] = &apiv1a1.NodeGPU{
+			Name:          node.Name,
+			Ready:         node.IsReady(),
+			Unschedulable: node.Unschedulable,
+			Capacity:      capacity,
+			Allocatable:   gpuNum(node.Allocatable),
+		}
+	}
+	tenants := make(map[string]*apiv1a1.TenantGPU)
+	getTenant := func(tenant string) *apiv1a1.TenantGPU {
+		tg, ok := tenants[tenant]
+		if !ok {
+			tg = &apiv1a1.TenantGPU{Tenant: tenant}
+			tenants[tenant] = tg
+		}
+		return tg
+	}
+	for _, pod := range pc.ListCachePointer(ctx, "") {
+		if pod.IsTerminated() {
+			continue
+		}
+		n := gpuNum(pod.ResourceRequests())
+		if n == 0 {
+			continue
+		}
+		if ng := nodes[pod.NodeName]; ng != nil {
+			ng.Requested += n
+		}
+		if tenant := scc.TenantOfNamespace(pod.Namespace); len(tenant) > 0 {
+			getTenant(tenant).Requested += n
+		}
+	}
+	if tc, ok := scc.GetTenantCache(); ok {
+		for _, t := range tc.ListCachePointer(ctx) {
+			quota, ok := t.Spec.Quota[tntv1al.ResourceRequestsNvidiaGPU]
+			if !ok {
+				quota, ok = t.Spec.Quota[tntv1al.ResourceNvidiaGPU]
+			}
+			if ok && quota.Value() > 0 {
+				getTenant(t.Name).Quota = quota.Value()
+			}
+		}
+	}
+
+	re := &apiv1a1.GPUSummary{
+		Nodes:   make([]apiv1a1.NodeGPU, 0, len(nodes)),
+		Tenants: []apiv1a1.TenantGPU{},
+	}
+	isSystem := xTenant == tntv1al.SystemTenant
+	for _, ng := range nodes {
+		if ng.Free = ng.Allocatable - ng.Requested; ng.Free < 0 {
+			ng.Free = 0
+		}
+		re.Capacity += ng.Capacity
+		re.Allocatable += ng.Allocatable
+		re.Requested += ng.Requested
+		re.Free += ng.Free
+		if ng.Free > 0 && ng.Ready && !ng.Unschedulable {
+			re.AvailableNodeNum++
+		}
+		re.Nodes = append(re.Nodes, *ng)
+	}
+	sort.Slice(re.Nodes, func(i, j int) bool {
+		if re.Nodes[i].Free != re.Nodes[j].Free {
+			return re.Nodes[i].Free > re.Nodes[j].Free
+		}
+		return re.Nodes[i].Name < re.Nodes[j].Name
+	})
+	if !isSystem {
+		re.Capacity, re.Allocatable, re.Requested, re.Nodes = 0, 0, 0, nil
+	}
+	for _, tg := range tenants {
+		if !isSystem && tg.Tenant != xTenant {
+			continue
+		}
+		re.Tenants = append(re.Tenants, *tg)
+	}
+	sort.Slice(re.Tenants, func(i, j int) bool {
+		return re.Tenants[i].Tenant < re.Tenants[j].Tenant
+	})
+	return re, nil
+}
+
+// gpuNum is the nvidia gpus in rl, of the current name and the deprecated one
+func gpuNum(rl corev1.ResourceList) int64 {
+	var re int64
+	for _, name := range []corev1.ResourceName{tntv1al.ResourceNvidiaGPU, resourceAlphaNvidiaGPU} {
+		if q, ok := rl[name]; ok {
+			re += q.Value()
+		}
+	}
+	return re
+}
@@ -0,0 +1,182 @@
+package helper
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	resv1b1 "github.com/caicloud/clientset/pkg/apis/resource/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+)
+
+// MachineFilter selects machines, empty fields select all. Tag is key or
+// key=value.
+type MachineFilter struct {
+	Cluster  string
+	Phase    string
+	Provider string
+	Tag      string
+}
+
+func (f *MachineFilter) match(m *resv1b1.Machine) bool {
+	if len(f.Cluster) > 0 && m.Spec.Cluster != f.Cluster {
+		return false
+	}
+	if len(f.Phase) > 0 && string(m.Status.Phase) != f.Phase {
+		return false
+	}
+	if len(f.Provider) > 0 && string(m.Spec.Provider) != f.Provider {
+		return false
+	}
+	if len(f.Tag) > 0 {
+		k, v, withValue := f.Tag, "", false
+		if i := strings.Index(f.Tag, "="); i >= 0 {
+			k, v, withValue = f.Tag[:i], f.Tag[i+1:], true
+		}
+		tv, ok := m.Spec.Tags[k]
+		if !ok || withValue && tv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ListMachineInventory returns the machines of the control cluster matching
+// filter sorted by cluster and name, and the hardware of them summed by
+// cluster
+func ListMachineInventory(ctx context.Context, c *cache.Cache, filter MachineFilter) ([]apiv1a1.MachineInventory, []apiv1a1.ClusterHardware) {
+	var (
+		machines []apiv1a1.MachineInventory
+		clusters = make(map[string]*clusterHardwareBuilder)
+	)
+	for _, m := range c.GetMachinesCache().ListCachePointer(ctx) {
+		if !filter.match(m) {
+			continue
+		}
+		mi := newMachineInventory(m)
+		b, ok := clusters[mi.Cluster]
+		if !ok {
+			b = &clusterHardwareBuilder{}
+			clusters[mi.Cluster] = b
+		}
+		b.add(m)
+		machines = append(machines, mi)
+	}
+	sort.Slice(machines, func(i, j int) bool {
+		if machines[i].Cluster != machines[j].Cluster {
+			return machines[i].Cluster < machines[j].Cluster
+		}
+		return machines[i].Name < machines[j].Name
+	})
+	hardware := make([]apiv1a1.ClusterHardware, 0, len(clusters))
+	for cluster, b := range clusters {
+		hardware = append(hardware, b.build(cluster))
+	}
+	sort.Slice(hardware, func(i, j int) bool {
+		return hardware[i].Cluster < hardware[j].Cluster
+	})
+	if machines == nil {
+		machines = []apiv1a1.MachineInventory{}
+	}
+	return machines, hardware
+}
+
+func newMachineInventory(m *resv1b1.Machine) apiv1a1.MachineInventory {
+	env := &m.Status.Environment
+	re := apiv1a1.MachineInventory{
+		Name:      m.Name,
+		Cluster:   m.Spec.Cluster,
+		IsMaster:  m.Spec.IsMaster,
+		Provider:  string(m.Spec.Provider),
+		Phase:     string(m.Status.Phase),
+		NodeRefer: m.Status.NodeRefer,
+		Addresses: make([]corev1.NodeAddress, 0, len(m.Spec.Address)),
+		Tags:      copyStringMap(m.Spec.Tags),
+		System: apiv1a1.MachineSystem{
+			Hostname:        env.SystemInfo.Hostname,
+			OS:              env.SystemInfo.OS,
+			Platform:        env.SystemInfo.Platform,
+			PlatformVersion: env.SystemInfo.PlatformVersion,
+			KernelVersion:   env.SystemInfo.KernelVersion,
+		},
+		Hardware: apiv1a1.MachineHardware{
+			CPUModel:         env.HardwareInfo.CPUModel,
+			CPUArch:          env.HardwareInfo.CPUArch,
+			CPUMHz:           env.HardwareInfo.CPUMHz,
+			CPUCores:         env.HardwareInfo.CPUCores,
+			CPUPhysicalCores: env.HardwareInfo.CPUPhysicalCores,
+			Memory:           *resource.NewQuantity(int64(env.HardwareInfo.MemoryTotal), resource.BinarySI),
+		},
+		Disks:              make([]apiv1a1.MachineDisk, 0, len(env.DiskInfo)),
+		NICs:               make([]apiv1a1.MachineNIC, 0, len(env.NicInfo)),
+		GPUs:               make([]apiv1a1.MachineGPU, 0, len(env.GPUInfo)),
+		LastTransitionTime: env.LastTransitionTime.Time,
+	}
+	if env.SystemInfo.BootTime > 0 {
+		re.System.BootTime = time.Unix(int64(env.SystemInfo.BootTime), 0)
+	}
+	for _, a := range m.Spec.Address {
+		re.Addresses = append(re.Addresses, corev1.NodeAddress{Type: corev1.NodeAddressType(a.Type), Address: a.Address})
+	}
+	for _, d := range env.DiskInfo {
+		re.Disks = append(re.Disks, apiv1a1.MachineDisk{
+			Device:     d.Device,
+			Type:       d.Type,
+			MountPoint: d.MountPoint,
+			Capacity:   *resource.NewQuantity(int64(d.Capacity), resource.BinarySI),
+		})
+	}
+	for _, n := range env.NicInfo {
+		re.NICs = append(re.NICs, apiv1a1.MachineNIC{
+			Name:         n.Name,
+			MTU:          n.MTU,
+			Speed:        n.Speed,
+			HardwareAddr: n.HardwareAddr,
+			Status:       n.Status,
+			Addrs:        n.Addrs,
+		})
+	}
+	for _, g := range env.GPUInfo {
+		re.GPUs = append(re.GPUs, apiv1a1.MachineGPU{
+			UUID:         g.UUID,
+			ProductName:  g.ProductName,
+			ProductBrand: g.ProductBrand,
+			MemoryTotal:  g.MemoryTotal,
+		})
+	}
+	return re
+}
+
+type clusterHardwareBuilder struct {
+	machineNum, cpuCores, diskNum, gpuNum int
+	memory, diskCapacity                  int64
+}
+
+func (b *clusterHardwareBuilder) add(m *resv1b1.Machine) {
+	env := &m.Status.Environment
+	b.machineNum++
+	b.cpuCores += env.HardwareInfo.CPUCores
+	b.memory += int64(env.HardwareInfo.MemoryTotal)
+	b.diskNum += len(env.DiskInfo)
+	for _, d := range env.DiskInfo {
+		b.diskCapacity += int64(d.Capacity)
+	}
+	b.gpuNum += len(env.GPUInfo)
+}
+
+func (b *clusterHardwareBuilder) build(cluster string) apiv1a1.ClusterHardware {
+	return apiv1a1.ClusterHardware{
+		Cluster:      cluster,
+		MachineNum:   b.machineNum,
+		CPUCores:     b.cpuCores,
+		Memory:       *resource.NewQuantity(b.memory, resource.BinarySI),
+		DiskNum:      b.diskNum,
+		DiskCapacity: *resource.NewQuantity(b.diskCapacity, resource.BinarySI),
+		GPUNum:       b.gpuNum,
+	}
+}
@@ -0,0 +1,143 @@
+package helper
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+	"github.com/caicloud/dashboard-admin/pkg/cache/crd"
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+const (
+	labelNodeRolePrefix = "node-role.kubernetes.io/"
+	labelNodeRole       = "kubernetes.io/role"
+)
+
+// ListNodeInfo returns the nodes of the cluster with the requests and limits
+// of the pods on them, ready is "true", "false" or empty for all, role is
+// empty for all
+func ListNodeInfo(ctx context.Context, c *cache.Cache, cluster, ready, role, sortBy string) ([]apiv1a1.NodeInfo, *errors.FormatError) {
+	scc, fe := c.GetSubClusterCaches(ctx, cluster)
+	if fe != nil {
+		return nil, fe
+	}
+	nc, ok := scc.GetNodeCache()
+	if !ok {
+		return nil, errors.NewError().SetErrorCacheDisabled(cluster, crd.CacheNameNode)
+	}
+	pc, ok := scc.GetPodCache()
+	if !ok {
+		return nil, errors.NewError().SetErrorCacheDisabled(cluster, crd.CacheNamePod)
+	}
+	re := []apiv1a1.NodeInfo{}
+	for _, node := range nc.ListCachePointer(ctx) {
+		ni := newNodeInfo(node)
+		if len(ready) > 0 && strconv.FormatBool(ni.Ready) != ready {
+			continue
+		}
+		if len(role) > 0 && ni.Role != role {
+			continue
+		}
+		for _, pod := range pc.ByIndex(crd.IndexPodNodeName, node.Name) {
+			if pod.IsTerminated() {
+				continue
+			}
+			ni.PodNum++
+			crd.AddResourceList(ni.Requests, pod.ResourceRequests())
+			crd.AddResourceList(ni.Limits, pod.ResourceLimits())
+		}
+		re = append(re, ni)
+	}
+	sortNodeInfo(re, sortBy)
+	return re, nil
+}
+
+func newNodeInfo(node *crd.NodeProjection) apiv1a1.NodeInfo {
+	re := apiv1a1.NodeInfo{
+		Name:          node.Name,
+		Role:          nodeRole(node.Labels),
+		Ready:         node.IsReady(),
+		Unschedulable: node.Unschedulable,
+		Addresses:     node.Addresses,
+		Capacity:      node.Capacity,
+		Allocatable:   node.Allocatable,
+		Requests:      corev1.ResourceList{},
+		Limits:        corev1.ResourceList{},
+		Conditions:    node.Conditions,
+		Taints:        node.Taints,
+	}
+	if q, ok := node.Allocatable[corev1.ResourcePods]; ok {
+		re.MaxPods = q.Value()
+	}
+	if re.Conditions == nil {
+		re.Conditions = []corev1.NodeCondition{}
+	}
+	if re.Taints == nil {
+		re.Taints = []corev1.Taint{}
+	}
+	return re
+}
+
+// nodeRole is master if the node has a master or control plane role label,
+// or the first other role, or node
+func nodeRole(labels map[string]string) string {
+	var roles []string
+	for k, v := range labels {
+		switch {
+		case strings.HasPrefix(k, labelNodeRolePrefix) && len(k) > len(labelNodeRolePrefix):
+			roles = append(roles, k[len(labelNodeRolePrefix):])
+		case k == labelNodeRole && len(v) > 0:
+			roles = append(roles, v)
+		}
+	}
+	sort.Strings(roles)
+	for _, r := range roles {
+		if r == apiv1a1.NodeRoleMaster || r == "control-plane" {
+			return apiv1a1.NodeRoleMaster
+		}
+	}
+	if len(roles) > 0 {
+		return roles[0]
+	}
+	return apiv1a1.NodeRoleNode
+}
+
+func sortNodeInfo(nodes []apiv1a1.NodeInfo, sortBy string) {
+	var ratio func(ni *apiv1a1.NodeInfo) float64
+	switch sortBy {
+	case apiv1a1.NodeSortCPU:
+		ratio = func(ni *apiv1a1.NodeInfo) float64 { return requestRatio(ni, corev1.ResourceCPU) }
+	case apiv1a1.NodeSortMemory:
+		ratio = func(ni *apiv1a1.NodeInfo) float64 { return requestRatio(ni, corev1.ResourceMemory) }
+	case apiv1a1.NodeSortPods:
+		ratio = func(ni *apiv1a1.NodeInfo) float64 {
+			if ni.MaxPods == 0 {
+				return 0
+			}
+			return float64(ni.PodNum) / float64(ni.MaxPods)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if ratio != nil {
+			if ri, rj := ratio(&nodes[i]), ratio(&nodes[j]); ri != rj {
+				return ri > rj
+			}
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+}
+
+func requestRatio(ni *apiv1a1.NodeInfo, name corev1.ResourceName) float64 {
+	allocatable, ok := ni.Allocatable[name]
+	if !ok || allocatable.IsZero() {
+		return 0
+	}
+	requests := ni.Requests[name]
+	return float64(requests.MilliValue()) / float64(allocatable.MilliValue())
+}
@@ -0,0 +1,40 @@
+package helper
+
+import (
+	"sort"
+
+	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+)
+
+// ListUpstreamStates returns the refresh states of the upstreams sorted by name
+func ListUpstreamStates(c *cache.Cache) []apiv1a1.UpstreamState {
+	states := c.RefreshStates()
+	re := make([]apiv1a1.UpstreamState, 0, len(states))
+	for _, s := range states {
+		us := apiv1a1.UpstreamState{
+			Name:                s.Name,
+			Slug:                s.Slug,
+			Host:                s.Host,
+			LastSuccess:         s.LastSuccess,
+			LastError:           s.LastError,
+			LastErrorMessage:    s.LastErrorMessage,
+			ConsecutiveFailures: s.ConsecutiveFailures,
+			NextRefresh:         s.NextRefresh,
+			Stale:               s.Stale,
+			Breaker: apiv1a1.BreakerState{
+				Status:              string(s.Breaker.Status),
+				ConsecutiveFailures: s.Breaker.ConsecutiveFailures,
+				OpenedAt:            s.Breaker.OpenedAt,
+				NextProbe:           s.Breaker.NextProbe,
+			},
+		}
+		if s.LastFailure != nil {
+			us.LastFailureReason = string(s.LastFailure.Reason)
+			us.LastFailureCode = s.LastFailure.HttpCode
+		}
+		re = append(re, us)
+	}
+	sort.Slice(re, func(i, j int) bool { return re[i].Name < re[j].Name })
+	return re
+}
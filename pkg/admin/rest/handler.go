@@ -8,8 +8,11 @@ import (
 	"github.com/caicloud/nirvana/log"
 
 	"github.com/caicloud/dashboard-admin/pkg/admin/fake"
+	"github.com/caicloud/dashboard-admin/pkg/admin/helper"
 	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
 	"github.com/caicloud/dashboard-admin/pkg/cache"
+	"github.com/caicloud/dashboard-admin/pkg/cache/api"
+	"github.com/caicloud/dashboard-admin/pkg/cache/crd"
 	"github.com/caicloud/dashboard-admin/pkg/util"
 )
 
@@ -20,18 +23,16 @@ func HandleListClusterInfo(c *cache.Cache) func(ctx context.Context,
 		startTime := time.Now()
 		log.Infof("%s start", logPrefix)
 		if fe := handleListClusterInfoPrework(xTenant, xUser, start, limit); fe != nil {
-			log.Errorf("%s handleListClusterInfoPrework failed, %v", fe.Error())
+			log.Errorf("%s handleListClusterInfoPrework failed, %v", logPrefix, fe.Error())
 			return nil, fe
 		}
 
-		// cis, e := helper.ListClusterInfo(c, xTenant)
-		// if e != nil {
-		// 	log.Errorf("%s failed, %v", logPrefix, e)
-		// 	return nil, errors.NewError().SetErrorInternalServerError(e)
-		// }
-		cis := fake.ListClusterInfo()
+		cis := helper.ListClusterInfo(ctx, c, xTenant)
 		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
 
+		if start > len(cis) {
+			start = len(cis)
+		}
 		end := util.GetStartLimitEnd(start, limit, len(cis))
 		return &apiv1a1.ClusterInfoList{
 			MetaData: apiv1a1.ListMetaData{Total: len(cis)},
@@ -40,6 +41,92 @@ func HandleListClusterInfo(c *cache.Cache) func(ctx context.Context,
 	}
 }
 
+func HandleListClusterCapacity(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser string, start, limit int, cluster string) (*apiv1a1.ClusterCapacityList, error) {
+	return func(ctx context.Context, xTenant, xUser string, start, limit int, cluster string) (*apiv1a1.ClusterCapacityList, error) {
+		logPrefix := fmt.Sprintf("HandleListClusterCapacity[%v:%v][%v:%v][cid:%v]", xTenant, xUser, start, limit, cluster)
+		startTime := time.Now()
+		log.Infof("%s start", logPrefix)
+		if fe := handleListClusterCapacityPrework(xTenant, xUser, start, limit); fe != nil {
+			log.Errorf("%s handleListClusterCapacityPrework failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		ccs := helper.ListClusterCapacity(ctx, c, cluster)
+		re := &apiv1a1.ClusterCapacityList{MetaData: apiv1a1.ListMetaData{Total: len(ccs)}}
+		for i := range ccs {
+			if ccs[i].Exceeded {
+				re.ExceededNum++
+			}
+		}
+
+		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+
+		if start > len(ccs) {
+			start = len(ccs)
+		}
+		end := util.GetStartLimitEnd(start, limit, len(ccs))
+		re.Items = ccs[start:end]
+		return re, nil
+	}
+}
+
+func HandleListMachineInventory(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser string, start, limit int, cluster, phase, provider, tag string) (*apiv1a1.MachineInventoryList, error) {
+	return func(ctx context.Context, xTenant, xUser string, start, limit int,
+		cluster, phase, provider, tag string) (*apiv1a1.MachineInventoryList, error) {
+		logPrefix := fmt.Sprintf("HandleListMachineInventory[%v:%v][%v:%v][%v:%v:%v:%v]",
+			xTenant, xUser, start, limit, cluster, phase, provider, tag)
+		startTime := time.Now()
+		log.Infof("%s start", logPrefix)
+		if fe := handleListMachineInventoryPrework(xTenant, xUser, start, limit); fe != nil {
+			log.Errorf("%s handleListMachineInventoryPrework failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		mis, chs := helper.ListMachineInventory(ctx, c, helper.MachineFilter{
+			Cluster:  cluster,
+			Phase:    phase,
+			Provider: provider,
+			Tag:      tag,
+		})
+
+		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+
+		if start > len(mis) {
+			start = len(mis)
+		}
+		end := util.GetStartLimitEnd(start, limit, len(mis))
+		return &apiv1a1.MachineInventoryList{
+			MetaData: apiv1a1.ListMetaData{Total: len(mis)},
+			Clusters: chs,
+			Items:    mis[start:end],
+		}, nil
+	}
+}
+
+func HandleGetClusterDetail(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser, cluster string) (*apiv1a1.ClusterDetail, error) {
+	return func(ctx context.Context, xTenant, xUser, cluster string) (*apiv1a1.ClusterDetail, error) {
+		logPrefix := fmt.Sprintf("HandleGetClusterDetail[%v:%v][cid:%v]", xTenant, xUser, cluster)
+		startTime := time.Now()
+		log.Infof("%s start", logPrefix)
+		if fe := handleGetClusterDetailPrework(xTenant, xUser, cluster); fe != nil {
+			log.Errorf("%s handleGetClusterDetailPrework failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		re, fe := helper.GetClusterDetail(ctx, c, cluster)
+		if fe != nil {
+			log.Errorf("%s GetClusterDetail failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+		return re, nil
+	}
+}
+
 func HandleGetMachineSummary(c *cache.Cache) func(ctx context.Context,
 	xTenant, xUser, cluster string) (*apiv1a1.MachineSummary, error) {
 	return func(ctx context.Context, xTenant, xUser, cluster string) (*apiv1a1.MachineSummary, error) {
@@ -47,7 +134,7 @@ func HandleGetMachineSummary(c *cache.Cache) func(ctx context.Context,
 		startTime := time.Now()
 		log.Infof("%s start", logPrefix)
 		if fe := handleGetMachineSummaryPrework(xTenant, xUser, cluster); fe != nil {
-			log.Errorf("%s handleGetMachineSummaryPrework failed, %v", fe.Error())
+			log.Errorf("%s handleGetMachineSummaryPrework failed, %v", logPrefix, fe.Error())
 			return nil, fe
 		}
 
@@ -58,6 +145,82 @@ func HandleGetMachineSummary(c *cache.Cache) func(ctx context.Context,
 	}
 }
 
+func HandleListNodeInfo(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser, cluster string, start, limit int, sortBy, ready, role string) (*apiv1a1.NodeInfoList, error) {
+	return func(ctx context.Context, xTenant, xUser, cluster string, start, limit int,
+		sortBy, ready, role string) (*apiv1a1.NodeInfoList, error) {
+		logPrefix := fmt.Sprintf("HandleListNodeInfo[%v:%v][cid:%v][%v:%v][%v:%v:%v]",
+			xTenant, xUser, cluster, start, limit, sortBy, ready, role)
+		startTime := time.Now()
+		log.Infof("%s start", logPrefix)
+		if fe := handleListNodeInfoPrework(xTenant, xUser, cluster, start, limit, sortBy, ready); fe != nil {
+			log.Errorf("%s handleListNodeInfoPrework failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		nis, fe := helper.ListNodeInfo(ctx, c, cluster, ready, role, sortBy)
+		if fe != nil {
+			log.Errorf("%s ListNodeInfo failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+
+		if start > len(nis) {
+			start = len(nis)
+		}
+		end := util.GetStartLimitEnd(start, limit, len(nis))
+		return &apiv1a1.NodeInfoList{
+			MetaData: apiv1a1.ListMetaData{Total: len(nis)},
+			Items:    nis[start:end],
+		}, nil
+	}
+}
+
+func HandleGetGPUSummary(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser, cluster string) (*apiv1a1.GPUSummary, error) {
+	return func(ctx context.Context, xTenant, xUser, cluster string) (*apiv1a1.GPUSummary, error) {
+		logPrefix := fmt.Sprintf("HandleGetGPUSummary[%v:%v][cid:%v]", xTenant, xUser, cluster)
+		startTime := time.Now()
+		log.Infof("%s start", logPrefix)
+		if fe := handleGetGPUSummaryPrework(xTenant, xUser, cluster); fe != nil {
+			log.Errorf("%s handleGetGPUSummaryPrework failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		re, fe := helper.GetGPUSummary(ctx, c, xTenant, cluster)
+		if fe != nil {
+			log.Errorf("%s GetGPUSummary failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+		return re, nil
+	}
+}
+
+func HandleGetTopConsumers(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser, cluster, sortBy string, top int) (*apiv1a1.TopConsumers, error) {
+	return func(ctx context.Context, xTenant, xUser, cluster, sortBy string, top int) (*apiv1a1.TopConsumers, error) {
+		logPrefix := fmt.Sprintf("HandleGetTopConsumers[%v:%v][cid:%v][%v:%v]", xTenant, xUser, cluster, sortBy, top)
+		startTime := time.Now()
+		log.Infof("%s start", logPrefix)
+		if fe := handleGetTopConsumersPrework(xTenant, xUser, sortBy, top); fe != nil {
+			log.Errorf("%s handleGetTopConsumersPrework failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		re, fe := helper.GetTopConsumers(ctx, c, xTenant, cluster, sortBy, top)
+		if fe != nil {
+			log.Errorf("%s GetTopConsumers failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+		return re, nil
+	}
+}
+
 func HandleGetLoadBalancersSummary(c *cache.Cache) func(ctx context.Context,
 	xTenant, xUser, cluster string) (*apiv1a1.LoadBalancersSummary, error) {
 	return func(ctx context.Context, xTenant, xUser, cluster string) (*apiv1a1.LoadBalancersSummary, error) {
@@ -65,7 +228,7 @@ func HandleGetLoadBalancersSummary(c *cache.Cache) func(ctx context.Context,
 		startTime := time.Now()
 		log.Infof("%s start", logPrefix)
 		if fe := handleGetLoadBalancersSummaryPrework(xTenant, xUser, cluster); fe != nil {
-			log.Errorf("%s handleGetLoadBalancersSummaryPrework failed, %v", fe.Error())
+			log.Errorf("%s handleGetLoadBalancersSummaryPrework failed, %v", logPrefix, fe.Error())
 			return nil, fe
 		}
 
@@ -83,7 +246,7 @@ func HandleListStorage(c *cache.Cache) func(ctx context.Context,
 		startTime := time.Now()
 		log.Infof("%s start", logPrefix)
 		if fe := handleListStoragePrework(xTenant, xUser, start, limit); fe != nil {
-			log.Errorf("%s handleListStoragePrework failed, %v", fe.Error())
+			log.Errorf("%s handleListStoragePrework failed, %v", logPrefix, fe.Error())
 			return nil, fe
 		}
 
@@ -106,13 +269,71 @@ func HandleGetContinuousIntegrationSummary(c *cache.Cache) func(ctx context.Cont
 		startTime := time.Now()
 		log.Infof("%s start", logPrefix)
 		if fe := handleGetContinuousIntegrationSummaryPrework(xTenant, xUser); fe != nil {
-			log.Errorf("%s handleGetContinuousIntegrationSummaryPrework failed, %v", fe.Error())
+			log.Errorf("%s handleGetContinuousIntegrationSummaryPrework failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		re := helper.GetContinuousIntegrationSummary(c, xTenant)
+		markStaleUpstreams(ctx, c, api.CacheNameDevopAdmin)
+
+		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+		return re, nil
+	}
+}
+
+func HandleListFailingPipelines(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser string, start, limit int) (*apiv1a1.FailingPipelineList, error) {
+	return func(ctx context.Context, xTenant, xUser string, start, limit int) (*apiv1a1.FailingPipelineList, error) {
+		logPrefix := fmt.Sprintf("HandleListFailingPipelines[%v:%v][%v:%v]", xTenant, xUser, start, limit)
+		startTime := time.Now()
+		log.Infof("%s start", logPrefix)
+		if fe := handleListFailingPipelinesPrework(xTenant, xUser, start, limit); fe != nil {
+			log.Errorf("%s handleListFailingPipelinesPrework failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		fps := helper.ListFailingPipelines(c, xTenant)
+		markStaleUpstreams(ctx, c, api.CacheNameDevopAdmin)
+
+		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+
+		if start > len(fps) {
+			start = len(fps)
+		}
+		end := util.GetStartLimitEnd(start, limit, len(fps))
+		return &apiv1a1.FailingPipelineList{
+			MetaData: apiv1a1.ListMetaData{Total: len(fps)},
+			Items:    fps[start:end],
+		}, nil
+	}
+}
+
+func HandleListWorkspaceCargo(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser string, start, limit int) (*apiv1a1.WorkspaceCargoList, error) {
+	return func(ctx context.Context, xTenant, xUser string, start, limit int) (*apiv1a1.WorkspaceCargoList, error) {
+		logPrefix := fmt.Sprintf("HandleListWorkspaceCargo[%v:%v][%v:%v]", xTenant, xUser, start, limit)
+		startTime := time.Now()
+		log.Infof("%s start", logPrefix)
+		if fe := handleListWorkspaceCargoPrework(xTenant, xUser, start, limit); fe != nil {
+			log.Errorf("%s handleListWorkspaceCargoPrework failed, %v", logPrefix, fe.Error())
 			return nil, fe
 		}
 
-		re := fake.GetContinuousIntegrationSummary()
+		re, fe := helper.ListWorkspaceCargo(c, xTenant)
+		if fe != nil {
+			log.Errorf("%s ListWorkspaceCargo failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+		markStaleUpstreams(ctx, c, api.CacheNameDevopAdmin, api.CacheNameCargo)
 
 		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+
+		re.MetaData.Total = len(re.Items)
+		if start > len(re.Items) {
+			start = len(re.Items)
+		}
+		end := util.GetStartLimitEnd(start, limit, len(re.Items))
+		re.Items = re.Items[start:end]
 		return re, nil
 	}
 }
@@ -124,14 +345,22 @@ func HandleGetCargoInfo(c *cache.Cache) func(ctx context.Context,
 		startTime := time.Now()
 		log.Infof("%s start", logPrefix)
 		if fe := handleGetCargoInfoPrework(xTenant, xUser, start, limit); fe != nil {
-			log.Errorf("%s handleGetCargoInfoPrework failed, %v", fe.Error())
+			log.Errorf("%s handleGetCargoInfoPrework failed, %v", logPrefix, fe.Error())
 			return nil, fe
 		}
 
-		ris := fake.ListRegistryInfo()
+		ris, fe := helper.ListRegistryInfo(c, xTenant)
+		if fe != nil {
+			log.Errorf("%s ListRegistryInfo failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+		markStaleUpstreams(ctx, c, api.CacheNameCargo)
 
 		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
 
+		if start > len(ris) {
+			start = len(ris)
+		}
 		end := util.GetStartLimitEnd(start, limit, len(ris))
 		return &apiv1a1.RegistryInfoList{
 			MetaData: apiv1a1.ListMetaData{Total: len(ris)},
@@ -140,6 +369,37 @@ func HandleGetCargoInfo(c *cache.Cache) func(ctx context.Context,
 	}
 }
 
+func HandleListCargoProjects(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser, registry string, start, limit int) (*apiv1a1.ProjectInfoList, error) {
+	return func(ctx context.Context, xTenant, xUser, registry string, start, limit int) (*apiv1a1.ProjectInfoList, error) {
+		logPrefix := fmt.Sprintf("HandleListCargoProjects[%v:%v][%v][%v:%v]", xTenant, xUser, registry, start, limit)
+		startTime := time.Now()
+		log.Infof("%s start", logPrefix)
+		if fe := handleListCargoProjectsPrework(xTenant, xUser, registry, start, limit); fe != nil {
+			log.Errorf("%s handleListCargoProjectsPrework failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		pis, fe := helper.ListProjectInfo(c, xTenant, registry)
+		if fe != nil {
+			log.Errorf("%s ListProjectInfo failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+		markStaleUpstreams(ctx, c, api.CacheNameCargo)
+
+		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+
+		if start > len(pis) {
+			start = len(pis)
+		}
+		end := util.GetStartLimitEnd(start, limit, len(pis))
+		return &apiv1a1.ProjectInfoList{
+			MetaData: apiv1a1.ListMetaData{Total: len(pis)},
+			Items:    pis[start:end],
+		}, nil
+	}
+}
+
 func HandleListEvent(c *cache.Cache) func(ctx context.Context,
 	xTenant, xUser string, start, limit int) (*apiv1a1.EventList, error) {
 	return func(ctx context.Context, xTenant, xUser string, start, limit int) (*apiv1a1.EventList, error) {
@@ -147,14 +407,17 @@ func HandleListEvent(c *cache.Cache) func(ctx context.Context,
 		startTime := time.Now()
 		log.Infof("%s start", logPrefix)
 		if fe := handleListEventPrework(xTenant, xUser, start, limit); fe != nil {
-			log.Errorf("%s handleListEventPrework failed, %v", fe.Error())
+			log.Errorf("%s handleListEventPrework failed, %v", logPrefix, fe.Error())
 			return nil, fe
 		}
 
-		evs := fake.ListEvent()
+		evs := helper.ListEvents(c, xTenant)
 
 		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
 
+		if start > len(evs) {
+			start = len(evs)
+		}
 		end := util.GetStartLimitEnd(start, limit, len(evs))
 		return &apiv1a1.EventList{
 			MetaData: apiv1a1.ListMetaData{Total: len(evs)},
@@ -170,7 +433,7 @@ func HandleGetAddonHealthSummary(c *cache.Cache) func(ctx context.Context,
 		startTime := time.Now()
 		log.Infof("%s start", logPrefix)
 		if fe := handleGetAddonHealthSummaryPrework(xTenant, xUser, cluster); fe != nil {
-			log.Errorf("%s handleGetAddonHealthSummaryPrework failed, %v", fe.Error())
+			log.Errorf("%s handleGetAddonHealthSummaryPrework failed, %v", logPrefix, fe.Error())
 			return nil, fe
 		}
 
@@ -188,7 +451,7 @@ func HandleGetKubeHealthSummary(c *cache.Cache) func(ctx context.Context,
 		startTime := time.Now()
 		log.Infof("%s start", logPrefix)
 		if fe := handleGetKubeHealthSummaryPrework(xTenant, xUser, cluster); fe != nil {
-			log.Errorf("%s handleGetKubeHealthSummaryPrework failed, %v", fe.Error())
+			log.Errorf("%s handleGetKubeHealthSummaryPrework failed, %v", logPrefix, fe.Error())
 			return nil, fe
 		}
 
@@ -206,7 +469,7 @@ func HandleGetAlertSummary(c *cache.Cache) func(ctx context.Context,
 		startTime := time.Now()
 		log.Infof("%s start", logPrefix)
 		if fe := handleGetAlertSummaryPrework(xTenant, xUser); fe != nil {
-			log.Errorf("%s handleGetAlertSummaryPrework failed, %v", fe.Error())
+			log.Errorf("%s handleGetAlertSummaryPrework failed, %v", logPrefix, fe.Error())
 			return nil, fe
 		}
 
@@ -224,11 +487,12 @@ func HandleGetPlatformSummary(c *cache.Cache) func(ctx context.Context,
 		startTime := time.Now()
 		log.Infof("%s start", logPrefix)
 		if fe := handleGetPlatformSummaryPrework(xTenant, xUser); fe != nil {
-			log.Errorf("%s handleGetPlatformSummaryPrework failed, %v", fe.Error())
+			log.Errorf("%s handleGetPlatformSummaryPrework failed, %v", logPrefix, fe.Error())
 			return nil, fe
 		}
 
 		re := fake.GetPlatformSummary()
+		markStaleUpstreams(ctx, c, api.CacheNameCauth)
 
 		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
 		return re, nil
@@ -242,11 +506,76 @@ func HandleGetAppSummary(c *cache.Cache) func(ctx context.Context,
 		startTime := time.Now()
 		log.Infof("%s start", logPrefix)
 		if fe := handleGetAppSummaryPrework(xTenant, xUser, cluster); fe != nil {
-			log.Errorf("%s handleGetAppSummaryPrework failed, %v", fe.Error())
+			log.Errorf("%s handleGetAppSummaryPrework failed, %v", logPrefix, fe.Error())
 			return nil, fe
 		}
 
-		re := fake.GetAppSummary()
+		re, fe := helper.GetAppSummary(ctx, c, xTenant, cluster)
+		if fe != nil {
+			log.Errorf("%s GetAppSummary failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+		return re, nil
+	}
+}
+
+func HandleListUpstreamState(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser string) (*apiv1a1.UpstreamStateList, error) {
+	return func(ctx context.Context, xTenant, xUser string) (*apiv1a1.UpstreamStateList, error) {
+		logPrefix := fmt.Sprintf("HandleListUpstreamState[%v:%v]", xTenant, xUser)
+		startTime := time.Now()
+		log.Infof("%s start", logPrefix)
+		if fe := handleListUpstreamStatePrework(xTenant, xUser); fe != nil {
+			log.Errorf("%s handleListUpstreamStatePrework failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		uss := helper.ListUpstreamStates(c)
+
+		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+		return &apiv1a1.UpstreamStateList{
+			MetaData: apiv1a1.ListMetaData{Total: len(uss)},
+			Items:    uss,
+		}, nil
+	}
+}
+
+func HandleListRecountReport(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser string) ([]crd.RecountReport, error) {
+	return func(ctx context.Context, xTenant, xUser string) ([]crd.RecountReport, error) {
+		logPrefix := fmt.Sprintf("HandleListRecountReport[%v:%v]", xTenant, xUser)
+		startTime := time.Now()
+		log.Infof("%s start", logPrefix)
+		if fe := handleListRecountReportPrework(xTenant, xUser); fe != nil {
+			log.Errorf("%s handleListRecountReportPrework failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		re := c.GetRecountReports()
+
+		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+		return re, nil
+	}
+}
+
+func HandleRefreshCache(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser, name string) (*api.RefreshResult, error) {
+	return func(ctx context.Context, xTenant, xUser, name string) (*api.RefreshResult, error) {
+		logPrefix := fmt.Sprintf("HandleRefreshCache[%v:%v][%v]", xTenant, xUser, name)
+		startTime := time.Now()
+		log.Infof("%s start", logPrefix)
+		if fe := handleRefreshCachePrework(c, xTenant, xUser, name); fe != nil {
+			log.Errorf("%s handleRefreshCachePrework failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
+
+		re, fe := c.TriggerRefresh(ctx, name)
+		if fe != nil {
+			log.Errorf("%s TriggerRefresh failed, %v", logPrefix, fe.Error())
+			return nil, fe
+		}
 
 		log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
 		return re, nil
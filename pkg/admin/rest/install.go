@@ -2,14 +2,25 @@ package rest
 
 import (
 	"fmt"
+	"net/http"
 	"path"
 
 	"github.com/caicloud/nirvana/definition"
+	"github.com/caicloud/nirvana/service"
 
 	"github.com/caicloud/dashboard-admin/pkg/cache"
 	"github.com/caicloud/dashboard-admin/pkg/constants"
 )
 
+// MethodTrigger is a POST which runs an action and returns its result
+const MethodTrigger definition.Method = "Trigger"
+
+func init() {
+	if e := service.RegisterMethod(MethodTrigger, http.MethodPost, http.StatusOK); e != nil {
+		panic(e)
+	}
+}
+
 var (
 	QueryParamStart = definition.Parameter{
 		Name:        constants.ParameterStart,
@@ -41,6 +52,66 @@ var (
 		Description: "request tenant",
 		Source:      definition.Header,
 	}
+	HeaderParamLastEventID = definition.Parameter{
+		Name:        constants.ParameterLastEventID,
+		Description: "id of the last received event to resume from",
+		Source:      definition.Header,
+	}
+	QueryParamLastEventID = definition.Parameter{
+		Name:        constants.ParameterLastEventIDQuery,
+		Description: "id of the last received event to resume from, for clients can not set header",
+		Source:      definition.Query,
+	}
+	PathParamCacheName = definition.Parameter{
+		Name:        constants.ParameterCacheName,
+		Description: "url safe name of upstream cache, the slug in upstream states",
+		Source:      definition.Path,
+	}
+	PathParamRegistry = definition.Parameter{
+		Name:        constants.ParameterRegistry,
+		Description: "cargo registry name",
+		Source:      definition.Path,
+	}
+	QueryParamSort = definition.Parameter{
+		Name:        constants.ParameterSort,
+		Description: "sort key",
+		Source:      definition.Query,
+	}
+	QueryParamReady = definition.Parameter{
+		Name:        constants.ParameterReady,
+		Description: "readiness filter, true or false",
+		Source:      definition.Query,
+	}
+	QueryParamRole = definition.Parameter{
+		Name:        constants.ParameterRole,
+		Description: "role filter",
+		Source:      definition.Query,
+	}
+	QueryParamPhase = definition.Parameter{
+		Name:        constants.ParameterPhase,
+		Description: "phase filter",
+		Source:      definition.Query,
+	}
+	QueryParamProvider = definition.Parameter{
+		Name:        constants.ParameterProvider,
+		Description: "provider filter",
+		Source:      definition.Query,
+	}
+	QueryParamTag = definition.Parameter{
+		Name:        constants.ParameterTag,
+		Description: "tag filter, key or key=value",
+		Source:      definition.Query,
+	}
+	QueryParamTop = definition.Parameter{
+		Name:        constants.ParameterTop,
+		Description: "number of top items, 10 by default and 100 at most",
+		Source:      definition.Query,
+	}
+	QueryParamCluster = definition.Parameter{
+		Name:        constants.ParameterCluster,
+		Description: "cluster id",
+		Source:      definition.Query,
+	}
 )
 
 func InitNirvanaDescriptors(c *cache.Cache) []definition.Descriptor {
@@ -62,6 +133,72 @@ func InitNirvanaDescriptors(c *cache.Cache) []definition.Descriptor {
 				},
 			},
 		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/capacities")),
+			Definitions: []definition.Definition{
+				{
+					Description: "list physical and overcommitted capacities of clusters, system tenant only",
+					Method:      definition.List,
+					Function:    HandleListClusterCapacity(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+						QueryParamStart, QueryParamLimit,
+						QueryParamCluster,
+					},
+					Results: commonResults,
+				},
+			},
+		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/consumers")),
+			Definitions: []definition.Definition{
+				{
+					Description: "get top namespaces, partitions and releases by resource usage of pods",
+					Method:      definition.Get,
+					Function:    HandleGetTopConsumers(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+						QueryParamCluster, QueryParamSort, QueryParamTop,
+					},
+					Results: commonResults,
+				},
+			},
+		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/machines")),
+			Definitions: []definition.Definition{
+				{
+					Description: "list machine hardware inventory of the control cluster, system tenant only",
+					Method:      definition.List,
+					Function:    HandleListMachineInventory(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+						QueryParamStart, QueryParamLimit,
+						QueryParamCluster, QueryParamPhase, QueryParamProvider, QueryParamTag,
+					},
+					Results: commonResults,
+				},
+			},
+		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/clusters/{%s}", constants.ParameterCluster)),
+			Definitions: []definition.Definition{
+				{
+					Description: "get cluster detail",
+					Method:      definition.Get,
+					Function:    HandleGetClusterDetail(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+						PathParamCluster,
+					},
+					Results: commonResults,
+				},
+			},
+		},
 		{
 			Path: path.Join(constants.RootPath, fmt.Sprintf("/clusters/{%s}/machines", constants.ParameterCluster)),
 			Definitions: []definition.Definition{
@@ -78,6 +215,40 @@ func InitNirvanaDescriptors(c *cache.Cache) []definition.Descriptor {
 				},
 			},
 		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/clusters/{%s}/nodes", constants.ParameterCluster)),
+			Definitions: []definition.Definition{
+				{
+					Description: "list cluster nodes with the resources allocated to pods, system tenant only",
+					Method:      definition.List,
+					Function:    HandleListNodeInfo(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+						PathParamCluster,
+						QueryParamStart, QueryParamLimit,
+						QueryParamSort, QueryParamReady, QueryParamRole,
+					},
+					Results: commonResults,
+				},
+			},
+		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/clusters/{%s}/gpus", constants.ParameterCluster)),
+			Definitions: []definition.Definition{
+				{
+					Description: "get cluster gpu capacity and allocation, nodes and totals for system tenant only",
+					Method:      definition.Get,
+					Function:    HandleGetGPUSummary(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+						PathParamCluster,
+					},
+					Results: commonResults,
+				},
+			},
+		},
 		{
 			Path: path.Join(constants.RootPath, fmt.Sprintf("/clusters/{%s}/loadbalancers", constants.ParameterCluster)),
 			Definitions: []definition.Definition{
@@ -126,6 +297,38 @@ func InitNirvanaDescriptors(c *cache.Cache) []definition.Descriptor {
 				},
 			},
 		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/ci/failingpipelines")),
+			Definitions: []definition.Definition{
+				{
+					Description: "list pipelines whose last finished record failed",
+					Method:      definition.List,
+					Function:    HandleListFailingPipelines(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+						QueryParamStart, QueryParamLimit,
+					},
+					Results: commonResults,
+				},
+			},
+		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/ci/cargoprojects")),
+			Definitions: []definition.Definition{
+				{
+					Description: "list workspaces with the cargo projects they push to",
+					Method:      definition.List,
+					Function:    HandleListWorkspaceCargo(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+						QueryParamStart, QueryParamLimit,
+					},
+					Results: commonResults,
+				},
+			},
+		},
 		{
 			Path: path.Join(constants.RootPath, fmt.Sprintf("/cargo")),
 			Definitions: []definition.Definition{
@@ -142,11 +345,28 @@ func InitNirvanaDescriptors(c *cache.Cache) []definition.Descriptor {
 				},
 			},
 		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/cargo/{%s}/projects", constants.ParameterRegistry)),
+			Definitions: []definition.Definition{
+				{
+					Description: "list projects of a cargo registry",
+					Method:      definition.List,
+					Function:    HandleListCargoProjects(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+						PathParamRegistry,
+						QueryParamStart, QueryParamLimit,
+					},
+					Results: commonResults,
+				},
+			},
+		},
 		{
 			Path: path.Join(constants.RootPath, fmt.Sprintf("/events")),
 			Definitions: []definition.Definition{
 				{
-					Description: "get events",
+					Description: "list the latest app events of all clusters, only their own for tenant users",
 					Method:      definition.List,
 					Function:    HandleListEvent(c),
 					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
@@ -236,5 +456,68 @@ func InitNirvanaDescriptors(c *cache.Cache) []definition.Descriptor {
 				},
 			},
 		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/upstreams")),
+			Definitions: []definition.Definition{
+				{
+					Description: "list refresh states of upstreams, system tenant only",
+					Method:      definition.List,
+					Function:    HandleListUpstreamState(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+					},
+					Results: commonResults,
+				},
+			},
+		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/recounts")),
+			Definitions: []definition.Definition{
+				{
+					Description: "list last counter recount reports of clusters with the drifts found, system tenant only",
+					Method:      definition.List,
+					Function:    HandleListRecountReport(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+					},
+					Results: commonResults,
+				},
+			},
+		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/caches/{%s}/refresh", constants.ParameterCacheName)),
+			Definitions: []definition.Definition{
+				{
+					Description: "refresh an upstream cache at once, admin only, or system tenant only for cauth before it is synced",
+					Method:      MethodTrigger,
+					Function:    HandleRefreshCache(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+						PathParamCacheName,
+					},
+					Results: commonResults,
+				},
+			},
+		},
+		{
+			Path: path.Join(constants.RootPath, fmt.Sprintf("/watch")),
+			Definitions: []definition.Definition{
+				{
+					Description: "watch changed summaries as server-sent events",
+					Method:      definition.Get,
+					Function:    HandleWatch(c),
+					Consumes:    []string{definition.MIMEAll}, Produces: []string{MIMEEventStream},
+					ErrorProduces: []string{MIMEEventStream, definition.MIMEJSON},
+					Parameters: []definition.Parameter{
+						HeaderParamXTenant, HeaderParamXUser,
+						QueryParamCluster, HeaderParamLastEventID, QueryParamLastEventID,
+					},
+					Results: []definition.Result{{Destination: definition.Error}},
+				},
+			},
+		},
 	}
 }
@@ -1,6 +1,14 @@
 package rest
 
 import (
+	"strconv"
+
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+
+	"github.com/caicloud/dashboard-admin/pkg/admin/helper"
+	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+	"github.com/caicloud/dashboard-admin/pkg/constants"
 	"github.com/caicloud/dashboard-admin/pkg/errors"
 )
 
@@ -35,10 +43,77 @@ func handleListClusterInfoPrework(xTenant, xUser string, start, limit int) *erro
 	return listPrework(xTenant, xUser, start, limit)
 }
 
+func handleListClusterCapacityPrework(xTenant, xUser string, start, limit int) *errors.FormatError {
+	if fe := listPrework(xTenant, xUser, start, limit); fe != nil {
+		return fe
+	}
+	if xTenant != tntv1al.SystemTenant {
+		return errors.NewError().SetErrorForbidden(xTenant, xUser, "list cluster capacities")
+	}
+	return nil
+}
+
+func handleListMachineInventoryPrework(xTenant, xUser string, start, limit int) *errors.FormatError {
+	if fe := listPrework(xTenant, xUser, start, limit); fe != nil {
+		return fe
+	}
+	if xTenant != tntv1al.SystemTenant {
+		return errors.NewError().SetErrorForbidden(xTenant, xUser, "list machines")
+	}
+	return nil
+}
+
+func handleGetClusterDetailPrework(xTenant, xUser, cluster string) *errors.FormatError {
+	return getClusterSubPrework(xTenant, xUser, cluster)
+}
+
 func handleGetMachineSummaryPrework(xTenant, xUser, cluster string) *errors.FormatError {
 	return getClusterSubPrework(xTenant, xUser, cluster)
 }
 
+func handleListNodeInfoPrework(xTenant, xUser, cluster string, start, limit int, sortBy, ready string) *errors.FormatError {
+	if fe := listPrework(xTenant, xUser, start, limit); fe != nil {
+		return fe
+	}
+	if len(cluster) == 0 {
+		return errors.NewError().SetErrorEmptyCluster()
+	}
+	switch sortBy {
+	case "", apiv1a1.NodeSortName, apiv1a1.NodeSortCPU, apiv1a1.NodeSortMemory, apiv1a1.NodeSortPods:
+	default:
+		return errors.NewError().SetErrorBadParameter(constants.ParameterSort, sortBy)
+	}
+	switch ready {
+	case "", "true", "false":
+	default:
+		return errors.NewError().SetErrorBadParameter(constants.ParameterReady, ready)
+	}
+	if xTenant != tntv1al.SystemTenant {
+		return errors.NewError().SetErrorForbidden(xTenant, xUser, "list nodes")
+	}
+	return nil
+}
+
+func handleGetTopConsumersPrework(xTenant, xUser, sortBy string, top int) *errors.FormatError {
+	if fe := getClusterAcrossPrework(xTenant, xUser); fe != nil {
+		return fe
+	}
+	switch sortBy {
+	case "", apiv1a1.ConsumerSortCPURequests, apiv1a1.ConsumerSortMemoryRequests,
+		apiv1a1.ConsumerSortCPULimits, apiv1a1.ConsumerSortMemoryLimits, apiv1a1.ConsumerSortPods:
+	default:
+		return errors.NewError().SetErrorBadParameter(constants.ParameterSort, sortBy)
+	}
+	if top < 0 || top > helper.MaxTopConsumerNum {
+		return errors.NewError().SetErrorBadParameter(constants.ParameterTop, strconv.Itoa(top))
+	}
+	return nil
+}
+
+func handleGetGPUSummaryPrework(xTenant, xUser, cluster string) *errors.FormatError {
+	return getClusterSubPrework(xTenant, xUser, cluster)
+}
+
 func handleGetLoadBalancersSummaryPrework(xTenant, xUser, cluster string) *errors.FormatError {
 	return getClusterSubPrework(xTenant, xUser, cluster)
 }
@@ -51,10 +126,28 @@ func handleGetContinuousIntegrationSummaryPrework(xTenant, xUser string) *errors
 	return getClusterAcrossPrework(xTenant, xUser)
 }
 
+func handleListFailingPipelinesPrework(xTenant, xUser string, start, limit int) *errors.FormatError {
+	return listPrework(xTenant, xUser, start, limit)
+}
+
+func handleListWorkspaceCargoPrework(xTenant, xUser string, start, limit int) *errors.FormatError {
+	return listPrework(xTenant, xUser, start, limit)
+}
+
 func handleGetCargoInfoPrework(xTenant, xUser string, start, limit int) *errors.FormatError {
 	return listPrework(xTenant, xUser, start, limit)
 }
 
+func handleListCargoProjectsPrework(xTenant, xUser, registry string, start, limit int) *errors.FormatError {
+	if fe := listPrework(xTenant, xUser, start, limit); fe != nil {
+		return fe
+	}
+	if len(registry) == 0 {
+		return errors.NewError().SetErrorMissParameter(constants.ParameterRegistry)
+	}
+	return nil
+}
+
 func handleListEventPrework(xTenant, xUser string, start, limit int) *errors.FormatError {
 	return listPrework(xTenant, xUser, start, limit)
 }
@@ -78,3 +171,44 @@ func handleGetPlatformSummaryPrework(xTenant, xUser string) *errors.FormatError
 func handleGetAppSummaryPrework(xTenant, xUser, cluster string) *errors.FormatError {
 	return getClusterSubPrework(xTenant, xUser, cluster)
 }
+
+func handleListUpstreamStatePrework(xTenant, xUser string) *errors.FormatError {
+	if fe := getClusterAcrossPrework(xTenant, xUser); fe != nil {
+		return fe
+	}
+	// hosts and upstream error bodies are infrastructure
+	if xTenant != tntv1al.SystemTenant {
+		return errors.NewError().SetErrorForbidden(xTenant, xUser, "list upstream states")
+	}
+	return nil
+}
+
+func handleListRecountReportPrework(xTenant, xUser string) *errors.FormatError {
+	if fe := getClusterAcrossPrework(xTenant, xUser); fe != nil {
+		return fe
+	}
+	if xTenant != tntv1al.SystemTenant {
+		return errors.NewError().SetErrorForbidden(xTenant, xUser, "list recount reports")
+	}
+	return nil
+}
+
+func handleWatchPrework(xTenant, xUser string) *errors.FormatError {
+	return getClusterAcrossPrework(xTenant, xUser)
+}
+
+func handleRefreshCachePrework(c *cache.Cache, xTenant, xUser, name string) *errors.FormatError {
+	if fe := getClusterAcrossPrework(xTenant, xUser); fe != nil {
+		return fe
+	}
+	if len(name) == 0 {
+		return errors.NewError().SetErrorMissParameter(constants.ParameterCacheName)
+	}
+	if fe := helper.CheckRefreshCache(c, xTenant, xUser, name); fe != nil {
+		return fe
+	}
+	if !c.AllowTrigger(xUser) {
+		return errors.NewError().SetErrorTooManyRequests(xUser, "refresh caches")
+	}
+	return nil
+}
@@ -1,8 +1,13 @@
 package rest
 
 import (
+	"context"
 	"strconv"
+	"strings"
 
+	"github.com/caicloud/nirvana/service"
+
+	"github.com/caicloud/dashboard-admin/pkg/cache"
 	"github.com/caicloud/dashboard-admin/pkg/errors"
 )
 
@@ -19,3 +24,33 @@ func ParamCheckStartAndLimit(start, limit int) (fe *errors.FormatError) {
 	}
 	return
 }
+
+const (
+	HeaderStaleUpstreams       = "X-Stale-Upstreams"
+	HeaderUnavailableUpstreams = "X-Unavailable-Upstreams"
+	// rfc 7234 5.5.1
+	warningResponseIsStale = `110 - "Response is Stale"`
+)
+
+// markStaleUpstreams tells the client that the response is built from stale
+// data if any of the upstreams is stale, and which upstreams are unavailable
+// by their open circuit breakers
+func markStaleUpstreams(ctx context.Context, c *cache.Cache, upstreams ...string) {
+	stale := c.StaleUpstreams(upstreams...)
+	unavailable := c.UnavailableUpstreams(upstreams...)
+	if len(stale) == 0 && len(unavailable) == 0 {
+		return
+	}
+	httpCtx := service.HTTPContextFrom(ctx)
+	if httpCtx == nil {
+		return
+	}
+	h := httpCtx.ResponseWriter().Header()
+	if len(stale) > 0 {
+		h.Set(HeaderStaleUpstreams, strings.Join(stale, ","))
+		h.Set("Warning", warningResponseIsStale)
+	}
+	if len(unavailable) > 0 {
+		h.Set(HeaderUnavailableUpstreams, strings.Join(unavailable, ","))
+	}
+}
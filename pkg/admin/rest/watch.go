@@ -0,0 +1,185 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/caicloud/nirvana/log"
+	"github.com/caicloud/nirvana/service"
+
+	"github.com/caicloud/dashboard-admin/pkg/admin/helper"
+	apiv1a1 "github.com/caicloud/dashboard-admin/pkg/apis/v1alpha1"
+	"github.com/caicloud/dashboard-admin/pkg/cache"
+	"github.com/caicloud/dashboard-admin/pkg/cache/notify"
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+const MIMEEventStream = "text/event-stream"
+
+// sseProducer writes errors returned before the stream starts as error events
+type sseProducer struct{}
+
+func (p *sseProducer) ContentType() string {
+	return MIMEEventStream
+}
+
+func (p *sseProducer) Produce(w io.Writer, v interface{}) error {
+	var data []byte
+	switch d := v.(type) {
+	case string:
+		data = []byte(d)
+	case []byte:
+		data = d
+	default:
+		b, e := json.Marshal(v)
+		if e != nil {
+			return e
+		}
+		data = b
+	}
+	return writeSSE(w, "", "error", data)
+}
+
+func init() {
+	if e := service.RegisterProducer(&sseProducer{}); e != nil {
+		panic(e)
+	}
+}
+
+// writeSSE writes one message, id is omitted if empty
+func writeSSE(w io.Writer, id, event string, data []byte) error {
+	var b bytes.Buffer
+	if len(id) > 0 {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	fmt.Fprintf(&b, "event: %s\n", event)
+	for _, line := range strings.Split(string(data), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	_, e := io.WriteString(w, b.String())
+	return e
+}
+
+// watchTopicGetters get the same data as the endpoints of the topics
+var watchTopicGetters = map[notify.Topic]func(ctx context.Context, c *cache.Cache,
+	xTenant, cluster string) (interface{}, *errors.FormatError){
+	notify.TopicClusters: func(ctx context.Context, c *cache.Cache, xTenant, cluster string) (interface{}, *errors.FormatError) {
+		cis := helper.ListClusterInfo(ctx, c, xTenant)
+		return &apiv1a1.ClusterInfoList{MetaData: apiv1a1.ListMetaData{Total: len(cis)}, Items: cis}, nil
+	},
+	notify.TopicApps: func(ctx context.Context, c *cache.Cache, xTenant, cluster string) (interface{}, *errors.FormatError) {
+		return helper.GetAppSummary(ctx, c, xTenant, cluster)
+	},
+	notify.TopicEvents: func(ctx context.Context, c *cache.Cache, xTenant, cluster string) (interface{}, *errors.FormatError) {
+		evs := helper.ListEvents(c, xTenant)
+		return &apiv1a1.EventList{MetaData: apiv1a1.ListMetaData{Total: len(evs)}, Items: evs}, nil
+	},
+	notify.TopicCI: func(ctx context.Context, c *cache.Cache, xTenant, cluster string) (interface{}, *errors.FormatError) {
+		return helper.GetContinuousIntegrationSummary(c, xTenant), nil
+	},
+}
+
+// watchTopics returns the topics pushed to a watcher, the ones of a cluster
+// need the cluster
+func watchTopics(cluster string) []notify.Topic {
+	if len(cluster) == 0 {
+		return []notify.Topic{notify.TopicClusters, notify.TopicEvents, notify.TopicCI}
+	}
+	return []notify.Topic{notify.TopicClusters, notify.TopicApps, notify.TopicEvents, notify.TopicCI}
+}
+
+type watchError struct {
+	Topic notify.Topic        `json:"topic"`
+	Error *errors.FormatError `json:"error"`
+}
+
+// HandleWatch streams the summaries of the changed topics as server-sent
+// events until the client goes away. Every message is an event named by its
+// topic, the last one of a push carries the id to resume from.
+func HandleWatch(c *cache.Cache) func(ctx context.Context,
+	xTenant, xUser, cluster, lastEventID, lastEventIDQuery string) error {
+	return func(ctx context.Context, xTenant, xUser, cluster, lastEventID, lastEventIDQuery string) error {
+		logPrefix := fmt.Sprintf("HandleWatch[%v:%v][cid:%v]", xTenant, xUser, cluster)
+		startTime := time.Now()
+		if len(lastEventID) == 0 {
+			lastEventID = lastEventIDQuery
+		}
+		log.Infof("%s start from %q", logPrefix, lastEventID)
+		if fe := handleWatchPrework(xTenant, xUser); fe != nil {
+			log.Errorf("%s handleWatchPrework failed, %v", logPrefix, fe.Error())
+			return fe
+		}
+		httpCtx := service.HTTPContextFrom(ctx)
+		if httpCtx == nil {
+			return errors.NewError().SetErrorInternalServerError(fmt.Errorf("no http context"))
+		}
+		resp := httpCtx.ResponseWriter()
+		flusher, ok := resp.(http.Flusher)
+		if !ok {
+			return errors.NewError().SetErrorInternalServerError(fmt.Errorf("streaming not supported"))
+		}
+
+		topics := watchTopics(cluster)
+		sub := c.Subscribe(notify.Filter{Tenant: xTenant, Cluster: cluster, Topics: topics}, lastEventID)
+		defer sub.Close()
+
+		resp.Header().Set("Content-Type", MIMEEventStream)
+		resp.Header().Set("Cache-Control", "no-cache")
+		resp.Header().Set("Connection", "keep-alive")
+		resp.Header().Set("X-Accel-Buffering", "no")
+		resp.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(c.WatchHeartbeat())
+		defer heartbeat.Stop()
+		for {
+			var e error
+			select {
+			case <-ctx.Done():
+				log.Infof("%s done in %v", logPrefix, time.Now().Sub(startTime))
+				return nil
+			case <-heartbeat.C:
+				_, e = io.WriteString(resp, ": heartbeat\n\n")
+			case <-sub.C():
+				e = pushWatchTopics(ctx, c, resp, sub, xTenant, cluster)
+			}
+			if e != nil {
+				// the client is gone
+				log.Infof("%s stopped in %v, %v", logPrefix, time.Now().Sub(startTime), e)
+				return nil
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func pushWatchTopics(ctx context.Context, c *cache.Cache, w io.Writer, sub *notify.Subscription,
+	xTenant, cluster string) error {
+	eventID, topics := sub.Next()
+	for i, topic := range topics {
+		id := ""
+		if i == len(topics)-1 {
+			id = eventID
+		}
+		event := string(topic)
+		data, fe := watchTopicGetters[topic](ctx, c, xTenant, cluster)
+		if fe != nil {
+			event, data = "error", &watchError{Topic: topic, Error: fe}
+		}
+		b, e := json.Marshal(data)
+		if e != nil {
+			return e
+		}
+		if e = writeSSE(w, id, event, b); e != nil {
+			return e
+		}
+	}
+	return nil
+}
@@ -35,13 +35,17 @@ type ClusterInfo struct {
 	Metadata ObjectMetaData `json:"metadata"`
 	// sys-admin
 	Physical *Physical `json:"physical,omitempty"`
-	// all
+	// sys-admin, physical and overcommitted capacities with headroom
+	Capacity *ClusterCapacity `json:"capacity,omitempty"`
+	// all, the capacities of Request and Limit are the overcommitted ones
 	Request   Logical `json:"request"`
 	Limit     Logical `json:"limit"`
 	NodeNum   int     `json:"nodeNum"`
 	AppNum    int     `json:"appNum"`
 	PodNum    int     `json:"podNum"`
 	IsControl bool    `json:"isControl"`
+	// built from the snapshot, the caches of the cluster are not synced yet
+	Stale bool `json:"stale,omitempty"`
 }
 
 type ClusterInfoList struct {
@@ -49,6 +53,93 @@ type ClusterInfoList struct {
 	Items    []ClusterInfo `json:"items"`
 }
 
+// ClusterDetail is the cluster without its auth
+type ClusterDetail struct {
+	Metadata         ObjectMetaData   `json:"metadata"`
+	DisplayName      string           `json:"displayName"`
+	Provider         string           `json:"provider"`
+	IsControlCluster bool             `json:"isControlCluster"`
+	IsHighAvailable  bool             `json:"isHighAvailable"`
+	Versions         *ClusterVersions `json:"versions,omitempty"`
+	Network          ClusterNetwork   `json:"network"`
+	Ratio            ClusterRatio     `json:"ratio"`
+	Phase            string           `json:"phase"`
+	// the latest transition of the conditions, the cluster does not record
+	// the one of its phase
+	LastTransitionTime time.Time          `json:"lastTransitionTime,omitempty"`
+	Conditions         []ClusterCondition `json:"conditions"`
+	Masters            []MachineThumbnail `json:"masters"`
+	Nodes              []MachineThumbnail `json:"nodes"`
+	// nil if the caches of the cluster are not started
+	Caches      []ClusterCacheState `json:"caches"`
+	CacheSynced bool                `json:"cacheSynced"`
+}
+
+type ClusterVersions struct {
+	MasterSets map[string]string `json:"masterSets,omitempty"`
+	NodeSets   map[string]string `json:"nodeSets,omitempty"`
+}
+
+type ClusterNetwork struct {
+	Type string `json:"type"`
+}
+
+type ClusterRatio struct {
+	CPUOverCommitRatio    float64 `json:"cpuOverCommitRatio"`
+	MemoryOverCommitRatio float64 `json:"memoryOverCommitRatio"`
+}
+
+type ClusterCondition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	LastHeartbeatTime  time.Time `json:"lastHeartbeatTime"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+}
+
+type MachineThumbnail struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+type ClusterCacheState struct {
+	Name   string `json:"name"`
+	Synced bool   `json:"synced"`
+}
+
+type ClusterCapacityList struct {
+	MetaData ListMetaData `json:"metadata"`
+	// clusters of which any resource is exceeded, of all the items
+	ExceededNum int               `json:"exceededNum"`
+	Items       []ClusterCapacity `json:"items"`
+}
+
+// ClusterCapacity is the physical and overcommitted capacity of a cluster
+// with the requests committed to it
+type ClusterCapacity struct {
+	Cluster string `json:"cluster"`
+	Phase   string `json:"phase"`
+	// built from the snapshot, the caches of the cluster are not synced yet
+	Stale     bool               `json:"stale,omitempty"`
+	Exceeded  bool               `json:"exceeded"`
+	Resources []ResourceCapacity `json:"resources"`
+}
+
+// ResourceCapacity is Logical = Capacity * Ratio, Headroom = Logical -
+// Requests, it is exceeded if Requests > Logical
+type ResourceCapacity struct {
+	Name        corev1.ResourceName `json:"name"`
+	Ratio       float64             `json:"ratio"`
+	Capacity    resource.Quantity   `json:"capacity"`
+	Allocatable resource.Quantity   `json:"allocatable"`
+	Logical     resource.Quantity   `json:"logical"`
+	Requests    resource.Quantity   `json:"requests"`
+	Limits      resource.Quantity   `json:"limits"`
+	Headroom    resource.Quantity   `json:"headroom"`
+	Exceeded    bool                `json:"exceeded"`
+}
+
 type Physical struct {
 	Capacity corev1.ResourceList `json:"capacity"`
 	Used     corev1.ResourceList `json:"used"`
@@ -74,6 +165,193 @@ type MachineLoad struct {
 	IsMaster bool   `json:"isMaster"`
 }
 
+// node
+
+// sort keys of nodes, the ones of resources sort by requests/allocatable
+// with the fullest first
+const (
+	NodeSortName   = "name"
+	NodeSortCPU    = "cpu"
+	NodeSortMemory = "memory"
+	NodeSortPods   = "pods"
+)
+
+const (
+	NodeRoleMaster = "master"
+	NodeRoleNode   = "node"
+)
+
+type NodeInfoList struct {
+	MetaData ListMetaData `json:"metadata"`
+	Items    []NodeInfo   `json:"items"`
+}
+
+// NodeInfo is a node with the resources allocated to its pods
+type NodeInfo struct {
+	Name          string                 `json:"name"`
+	Role          string                 `json:"role"`
+	Ready         bool                   `json:"ready"`
+	Unschedulable bool                   `json:"unschedulable"`
+	Addresses     []corev1.NodeAddress   `json:"addresses,omitempty"`
+	Capacity      corev1.ResourceList    `json:"capacity"`
+	Allocatable   corev1.ResourceList    `json:"allocatable"`
+	Requests      corev1.ResourceList    `json:"requests"`
+	Limits        corev1.ResourceList    `json:"limits"`
+	PodNum        int                    `json:"podNum"`
+	MaxPods       int64                  `json:"maxPods"`
+	Conditions    []corev1.NodeCondition `json:"conditions"`
+	Taints        []corev1.Taint         `json:"taints"`
+}
+
+// top consumers
+
+// sort keys of resource consumers, the largest first
+const (
+	ConsumerSortCPURequests    = "cpuRequests"
+	ConsumerSortMemoryRequests = "memoryRequests"
+	ConsumerSortCPULimits      = "cpuLimits"
+	ConsumerSortMemoryLimits   = "memoryLimits"
+	ConsumerSortPods           = "pods"
+)
+
+// TopConsumers is the top namespaces, partitions and releases of the
+// clusters by SortBy, pods terminated are not counted. A partition is just a
+// namespace owned by a tenant, so Partitions are the namespaces with Tenant
+// set, ranked among themselves.
+type TopConsumers struct {
+	SortBy     string             `json:"sortBy"`
+	Namespaces []ResourceConsumer `json:"namespaces"`
+	Partitions []ResourceConsumer `json:"partitions"`
+	Releases   []ResourceConsumer `json:"releases"`
+}
+
+// ResourceConsumer is the pods of a namespace, partition or release, Name is
+// the same as Namespace for namespaces and partitions
+type ResourceConsumer struct {
+	Cluster   string              `json:"cluster"`
+	Namespace string              `json:"namespace"`
+	Name      string              `json:"name"`
+	Tenant    string              `json:"tenant,omitempty"`
+	PodNum    int                 `json:"podNum"`
+	Requests  corev1.ResourceList `json:"requests"`
+	Limits    corev1.ResourceList `json:"limits"`
+}
+
+// gpu
+
+// GPUSummary is the nvidia gpus of a cluster, requested counts the pods not
+// terminated, tenant users see only the availability and their own tenant
+type GPUSummary struct {
+	// sys-admin
+	Capacity    int64 `json:"capacity,omitempty"`
+	Allocatable int64 `json:"allocatable,omitempty"`
+	Requested   int64 `json:"requested,omitempty"`
+	// all
+	Free int64 `json:"free"`
+	// nodes having free gpus and schedulable
+	AvailableNodeNum int `json:"availableNodeNum"`
+	// sys-admin, nodes having gpus, the most free first
+	Nodes []NodeGPU `json:"nodes,omitempty"`
+	// tenants having gpu quota or requests, only their own for tenant users
+	Tenants []TenantGPU `json:"tenants"`
+}
+
+type NodeGPU struct {
+	Name          string `json:"name"`
+	Ready         bool   `json:"ready"`
+	Unschedulable bool   `json:"unschedulable"`
+	Capacity      int64  `json:"capacity"`
+	Allocatable   int64  `json:"allocatable"`
+	Requested     int64  `json:"requested"`
+	Free          int64  `json:"free"`
+}
+
+type TenantGPU struct {
+	Tenant    string `json:"tenant"`
+	Quota     int64  `json:"quota"`
+	Requested int64  `json:"requested"`
+}
+
+// machine inventory
+
+type MachineInventoryList struct {
+	MetaData ListMetaData `json:"metadata"`
+	// of all the machines matching the filters, by cluster binding
+	Clusters []ClusterHardware  `json:"clusters"`
+	Items    []MachineInventory `json:"items"`
+}
+
+// ClusterHardware is the sum of the hardware of the machines bound to a
+// cluster, empty cluster for the unbound ones
+type ClusterHardware struct {
+	Cluster      string            `json:"cluster"`
+	MachineNum   int               `json:"machineNum"`
+	CPUCores     int               `json:"cpuCores"`
+	Memory       resource.Quantity `json:"memory"`
+	DiskNum      int               `json:"diskNum"`
+	DiskCapacity resource.Quantity `json:"diskCapacity"`
+	GPUNum       int               `json:"gpuNum"`
+}
+
+// MachineInventory is a machine without its auth and provider config
+type MachineInventory struct {
+	Name               string               `json:"name"`
+	Cluster            string               `json:"cluster"`
+	IsMaster           bool                 `json:"isMaster"`
+	Provider           string               `json:"provider"`
+	Phase              string               `json:"phase"`
+	NodeRefer          string               `json:"nodeRefer,omitempty"`
+	Addresses          []corev1.NodeAddress `json:"addresses"`
+	Tags               map[string]string    `json:"tags,omitempty"`
+	System             MachineSystem        `json:"system"`
+	Hardware           MachineHardware      `json:"hardware"`
+	Disks              []MachineDisk        `json:"disks"`
+	NICs               []MachineNIC         `json:"nics"`
+	GPUs               []MachineGPU         `json:"gpus"`
+	LastTransitionTime time.Time            `json:"lastTransitionTime"`
+}
+
+type MachineSystem struct {
+	Hostname        string    `json:"hostname"`
+	OS              string    `json:"os"`
+	Platform        string    `json:"platform"`
+	PlatformVersion string    `json:"platformVersion"`
+	KernelVersion   string    `json:"kernelVersion"`
+	BootTime        time.Time `json:"bootTime"`
+}
+
+type MachineHardware struct {
+	CPUModel         string            `json:"cpuModel"`
+	CPUArch          string            `json:"cpuArch"`
+	CPUMHz           float64           `json:"cpuMHz"`
+	CPUCores         int               `json:"cpuCores"`
+	CPUPhysicalCores int               `json:"cpuPhysicalCores"`
+	Memory           resource.Quantity `json:"memory"`
+}
+
+type MachineDisk struct {
+	Device     string            `json:"device"`
+	Type       string            `json:"type"`
+	MountPoint string            `json:"mountPoint"`
+	Capacity   resource.Quantity `json:"capacity"`
+}
+
+type MachineNIC struct {
+	Name         string   `json:"name"`
+	MTU          string   `json:"mtu"`
+	Speed        string   `json:"speed"`
+	HardwareAddr string   `json:"hardwareAddr"`
+	Status       string   `json:"status"`
+	Addrs        []string `json:"addrs"`
+}
+
+type MachineGPU struct {
+	UUID         string `json:"uuid"`
+	ProductName  string `json:"productName"`
+	ProductBrand string `json:"productBrand"`
+	MemoryTotal  string `json:"memoryTotal"`
+}
+
 // load balancer
 
 type LoadBalancersSummary struct {
@@ -115,6 +393,104 @@ type StorageSet struct {
 type ContinuousIntegrationSummary struct {
 	WorkspaceNum int `json:"workspaceNum"`
 	PipelineNum  int `json:"pipelineNum"`
+	PipelineStats
+	// sys-admin
+	Tenants []TenantPipelineStats `json:"tenants,omitempty"`
+	// all
+	Workspaces []WorkspacePipelineStats `json:"workspaces"`
+}
+
+// PipelineStats of the recent records of pipelines, durations are in seconds
+// of the finished records
+type PipelineStats struct {
+	RecordNum  int `json:"recordNum"`
+	SuccessNum int `json:"successNum"`
+	FailedNum  int `json:"failedNum"`
+	RunningNum int `json:"runningNum"`
+	// success in the succeeded and failed ones, 0 if none
+	SuccessRate    float64           `json:"successRate"`
+	AvgDuration    float64           `json:"avgDuration"`
+	P95Duration    float64           `json:"p95Duration"`
+	RecentFailures []PipelineFailure `json:"recentFailures"`
+}
+
+type TenantPipelineStats struct {
+	Tenant       string `json:"tenant"`
+	WorkspaceNum int    `json:"workspaceNum"`
+	PipelineNum  int    `json:"pipelineNum"`
+	PipelineStats
+}
+
+type WorkspacePipelineStats struct {
+	Tenant      string `json:"tenant,omitempty"`
+	Workspace   string `json:"workspace"`
+	PipelineNum int    `json:"pipelineNum"`
+	PipelineStats
+}
+
+type PipelineFailure struct {
+	Tenant       string    `json:"tenant,omitempty"`
+	Workspace    string    `json:"workspace"`
+	Pipeline     string    `json:"pipeline"`
+	RecordID     string    `json:"recordId"`
+	RecordName   string    `json:"recordName"`
+	ErrorMessage string    `json:"errorMessage"`
+	StartTime    time.Time `json:"startTime"`
+	EndTime      time.Time `json:"endTime"`
+}
+
+type FailingPipelineList struct {
+	MetaData ListMetaData      `json:"metadata"`
+	Items    []FailingPipeline `json:"items"`
+}
+
+// FailingPipeline is a pipeline whose last finished record failed
+type FailingPipeline struct {
+	Tenant    string `json:"tenant,omitempty"`
+	Workspace string `json:"workspace"`
+	Pipeline  string `json:"pipeline"`
+	Alias     string `json:"alias,omitempty"`
+	Owner     string `json:"owner,omitempty"`
+	// failed ones in the recent records
+	FailedNum   int             `json:"failedNum"`
+	LastFailure PipelineFailure `json:"lastFailure"`
+}
+
+// WorkspaceCargoStatus tells if the cargo project a workspace pushes to exists
+type WorkspaceCargoStatus string
+
+const (
+	WorkspaceCargoLinked           WorkspaceCargoStatus = "Linked"
+	WorkspaceCargoNotConfigured    WorkspaceCargoStatus = "NotConfigured"
+	WorkspaceCargoRegistryNotFound WorkspaceCargoStatus = "RegistryNotFound"
+	WorkspaceCargoProjectNotFound  WorkspaceCargoStatus = "ProjectNotFound"
+)
+
+type WorkspaceCargoList struct {
+	MetaData ListMetaData `json:"metadata"`
+	// workspaces not linked to a cargo project, of all the items
+	RegistryNotFoundNum int              `json:"registryNotFoundNum"`
+	ProjectNotFoundNum  int              `json:"projectNotFoundNum"`
+	Items               []WorkspaceCargo `json:"items"`
+}
+
+// WorkspaceCargo is a workspace with the cargo project its pipelines push to
+type WorkspaceCargo struct {
+	Tenant    string               `json:"tenant"`
+	Workspace string               `json:"workspace"`
+	Registry  string               `json:"registry,omitempty"`
+	Project   string               `json:"project,omitempty"`
+	Host      string               `json:"host,omitempty"`
+	Status    WorkspaceCargoStatus `json:"status"`
+	Images    []CargoImage         `json:"images"`
+}
+
+type CargoImage struct {
+	Name           string            `json:"name"`
+	TagNum         int64             `json:"tagNum"`
+	PullNum        int64             `json:"pullNum"`
+	Size           resource.Quantity `json:"size"`
+	LastUpdateTime time.Time         `json:"lastUpdateTime"`
 }
 
 // cargo
@@ -125,20 +501,45 @@ type RegistryInfoList struct {
 }
 
 type RegistryInfo struct {
-	Name       string `json:"name"`
-	ProjectNum int    `json:"projectNum"`
-	ImageNum   int    `json:"imageNum"`
-	DiskUsage  string `json:"diskUsage"`
+	Name       string            `json:"name"`
+	ProjectNum int               `json:"projectNum"`
+	ImageNum   int               `json:"imageNum"`
+	DiskUsage  resource.Quantity `json:"diskUsage"`
+}
+
+type ProjectInfoList struct {
+	MetaData ListMetaData  `json:"metadata"`
+	Items    []ProjectInfo `json:"items"`
+}
+
+// ProjectInfo is a cargo project with the usage of its repositories
+type ProjectInfo struct {
+	Name        string `json:"name"`
+	Registry    string `json:"registry"`
+	Description string `json:"description,omitempty"`
+	IsPublic    bool   `json:"isPublic"`
+	// tenants which see the project, only for system tenant
+	Tenants      []string          `json:"tenants,omitempty"`
+	ImageNum     int               `json:"imageNum"`
+	TagNum       int64             `json:"tagNum"`
+	PullNum      int64             `json:"pullNum"`
+	StorageUsage resource.Quantity `json:"storageUsage"`
+	CreationTime time.Time         `json:"creationTime"`
+	// repositories are from the last known refresh
+	Stale bool `json:"stale,omitempty"`
 }
 
 // event
 
+// Event is an app created, rolled out or deleted, the latest ones kept in
+// memory since start
 type Event struct {
 	Type    string    `json:"type"`
 	Result  string    `json:"result"`
 	Time    time.Time `json:"time"`
 	User    string    `json:"user"`
 	Tenant  string    `json:"tenant"`
+	Cluster string    `json:"cluster,omitempty"`
 	Message string    `json:"message"`
 }
 
@@ -196,3 +597,35 @@ type AppSummary struct {
 	UpdatingNum int `json:"updatingNum"`
 	AbnormalNum int `json:"abnormalNum"`
 }
+
+// upstreams
+
+type UpstreamStateList struct {
+	MetaData ListMetaData    `json:"metadata"`
+	Items    []UpstreamState `json:"items"`
+}
+
+// UpstreamState is the refresh state of an upstream, system tenant only
+type UpstreamState struct {
+	Name             string    `json:"name"`
+	Slug             string    `json:"slug"`
+	Host             string    `json:"host"`
+	LastSuccess      time.Time `json:"lastSuccess"`
+	LastError        time.Time `json:"lastError"`
+	LastErrorMessage string    `json:"lastErrorMessage,omitempty"`
+	// reason and http code of the last error if it is from the upstream
+	LastFailureReason   string       `json:"lastFailureReason,omitempty"`
+	LastFailureCode     int          `json:"lastFailureCode,omitempty"`
+	ConsecutiveFailures int          `json:"consecutiveFailures"`
+	NextRefresh         time.Time    `json:"nextRefresh"`
+	Stale               bool         `json:"stale"`
+	Breaker             BreakerState `json:"breaker"`
+}
+
+// BreakerState is the circuit breaker of an upstream, open means its data is temporarily unavailable
+type BreakerState struct {
+	Status              string    `json:"status"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	OpenedAt            time.Time `json:"openedAt,omitempty"`
+	NextProbe           time.Time `json:"nextProbe,omitempty"`
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/caicloud/nirvana/log"
+)
+
+type BreakerStatus string
+
+const (
+	BreakerClosed   BreakerStatus = "closed"
+	BreakerOpen     BreakerStatus = "open"
+	BreakerHalfOpen BreakerStatus = "halfOpen"
+)
+
+type BreakerConfig struct {
+	// consecutive failures opening the breaker, 0 means never open
+	FailureThreshold int
+	// wait in open before probing
+	OpenTimeout time.Duration
+	// consecutive successful probes closing the breaker
+	SuccessThreshold int
+}
+
+// BreakerState is the state of the circuit breaker of an upstream
+type BreakerState struct {
+	Status              BreakerStatus `json:"status"`
+	ConsecutiveFailures int           `json:"consecutiveFailures"`
+	OpenedAt            time.Time     `json:"openedAt,omitempty"`
+	NextProbe           time.Time     `json:"nextProbe,omitempty"`
+}
+
+// ErrBreakerOpen is returned without calling the upstream when it is open
+type ErrBreakerOpen struct {
+	Service   string
+	NextProbe time.Time
+}
+
+func (e *ErrBreakerOpen) Error() string {
+	return fmt.Sprintf("circuit breaker of %s is open until %v", e.Service, e.NextProbe.Format(time.RFC3339))
+}
+
+// Breaker is a http.RoundTripper failing fast after the upstream failed
+// FailureThreshold times in a row. After OpenTimeout one request at a time
+// probes the upstream, SuccessThreshold successes close the breaker and any
+// failure opens it again. Transport errors and 5xx responses are failures.
+// Requests of a context from notCountedByBreaker are not counted, for the
+// failures of only a part of the upstream, but still fail fast unless closed.
+type Breaker struct {
+	service string
+	rt      http.RoundTripper
+	cfg     BreakerConfig
+
+	lock      sync.Mutex
+	state     BreakerState
+	successes int
+	probing   bool
+	// increased on every status change, requests admitted in an older
+	// generation are not counted when done
+	generation uint64
+}
+
+type breakerNotCountedKey struct{}
+
+// notCountedByBreaker returns a context whose requests are not counted by the
+// breaker, they go only if it is closed
+func notCountedByBreaker(ctx context.Context) context.Context {
+	return context.WithValue(ctx, breakerNotCountedKey{}, true)
+}
+
+func NewBreaker(service string, rt http.RoundTripper, cfg BreakerConfig) *Breaker {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if cfg.SuccessThreshold < 1 {
+		cfg.SuccessThreshold = 1
+	}
+	return &Breaker{
+		service: service,
+		rt:      rt,
+		cfg:     cfg,
+		state:   BreakerState{Status: BreakerClosed},
+	}
+}
+
+func (b *Breaker) RoundTrip(req *http.Request) (*http.Response, error) {
+	if notCounted, _ := req.Context().Value(breakerNotCountedKey{}).(bool); notCounted {
+		if e := b.closed(); e != nil {
+			return nil, e
+		}
+		return b.rt.RoundTrip(req)
+	}
+	generation, e := b.allow()
+	if e != nil {
+		return nil, e
+	}
+	resp, e := b.rt.RoundTrip(req)
+	b.done(generation, e == nil && resp.StatusCode < http.StatusInternalServerError)
+	return resp, e
+}
+
+// allow checks if a request may go, and turns open into half open if it is
+// time to probe. It returns the generation the request is admitted in.
+func (b *Breaker) allow() (uint64, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	switch b.state.Status {
+	case BreakerOpen:
+		if time.Now().Before(b.state.NextProbe) {
+			return 0, &ErrBreakerOpen{Service: b.service, NextProbe: b.state.NextProbe}
+		}
+		log.Infof("circuit breaker of %s half open, probing", b.service)
+		b.state.Status = BreakerHalfOpen
+		b.generation++
+		b.successes = 0
+		b.probing = true
+	case BreakerHalfOpen:
+		if b.probing {
+			return 0, &ErrBreakerOpen{Service: b.service, NextProbe: b.state.NextProbe}
+		}
+		b.probing = true
+	}
+	return b.generation, nil
+}
+
+// closed checks if the breaker is closed, a request not counted must not
+// take the place of a probe
+func (b *Breaker) closed() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state.Status != BreakerClosed {
+		return &ErrBreakerOpen{Service: b.service, NextProbe: b.state.NextProbe}
+	}
+	return nil
+}
+
+// done counts the result of a request admitted in generation, it is ignored
+// if the status changed since, like a request admitted in closed finishing
+// in half open, which is not a probe
+func (b *Breaker) done(generation uint64, success bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if generation != b.generation {
+		return
+	}
+	halfOpen := b.state.Status == BreakerHalfOpen
+	if halfOpen {
+		b.probing = false
+	}
+	if success {
+		b.state.ConsecutiveFailures = 0
+		if !halfOpen {
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.SuccessThreshold {
+			log.Infof("circuit breaker of %s closed", b.service)
+			b.state = BreakerState{Status: BreakerClosed}
+			b.generation++
+		}
+		return
+	}
+	b.state.ConsecutiveFailures++
+	if halfOpen || b.cfg.FailureThreshold > 0 && b.state.Status == BreakerClosed &&
+		b.state.ConsecutiveFailures >= b.cfg.FailureThreshold {
+		b.open()
+	}
+}
+
+// open must be called with lock held
+func (b *Breaker) open() {
+	now := time.Now()
+	b.state.Status = BreakerOpen
+	b.generation++
+	b.state.OpenedAt = now
+	b.state.NextProbe = now.Add(b.cfg.OpenTimeout)
+	log.Warningf("circuit breaker of %s opened after %d failures, probe at %v",
+		b.service, b.state.ConsecutiveFailures, b.state.NextProbe)
+}
+
+func (b *Breaker) State() BreakerState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTransport answers the status of the path, or fails without a response
+// if it is 0
+type testTransport struct {
+	status map[string]int
+	calls  map[string]int
+}
+
+func newTestTransport() *testTransport {
+	return &testTransport{status: map[string]int{}, calls: map[string]int{}}
+}
+
+func (t *testTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls[req.URL.Path]++
+	code := t.status[req.URL.Path]
+	if code == 0 {
+		return nil, fmt.Errorf("connection refused")
+	}
+	return &http.Response{StatusCode: code, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func roundTrip(b *Breaker, ctx context.Context, path string) error {
+	req, _ := http.NewRequest(http.MethodGet, "http://upstream"+path, nil)
+	resp, e := b.RoundTrip(req.WithContext(ctx))
+	if e == nil {
+		resp.Body.Close()
+	}
+	return e
+}
+
+func expectBreaker(t *testing.T, b *Breaker, status BreakerStatus) {
+	t.Helper()
+	if got := b.State().Status; got != status {
+		t.Fatalf("expect breaker %s, got %s", status, got)
+	}
+}
+
+func TestBreakerOpenAndClose(t *testing.T) {
+	tt := newTestTransport()
+	b := NewBreaker("test", tt, BreakerConfig{FailureThreshold: 2, OpenTimeout: 20 * time.Millisecond, SuccessThreshold: 2})
+	ctx := context.Background()
+
+	tt.status["/"] = http.StatusInternalServerError
+	roundTrip(b, ctx, "/")
+	expectBreaker(t, b, BreakerClosed)
+	roundTrip(b, ctx, "/")
+	expectBreaker(t, b, BreakerOpen)
+	if _, ok := roundTrip(b, ctx, "/").(*ErrBreakerOpen); !ok || tt.calls["/"] != 2 {
+		t.Fatalf("open breaker should fail fast, calls %d", tt.calls["/"])
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	tt.status["/"] = http.StatusOK
+	if e := roundTrip(b, ctx, "/"); e != nil {
+		t.Fatalf("probe failed, %v", e)
+	}
+	expectBreaker(t, b, BreakerHalfOpen)
+	if e := roundTrip(b, ctx, "/"); e != nil {
+		t.Fatalf("probe failed, %v", e)
+	}
+	expectBreaker(t, b, BreakerClosed)
+}
+
+func TestBreakerGeneration(t *testing.T) {
+	b := NewBreaker("test", newTestTransport(), BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour})
+
+	// admitted in closed, done after the breaker opened
+	closedGen, e := b.allow()
+	if e != nil {
+		t.Fatalf("closed breaker should allow, %v", e)
+	}
+	b.done(closedGen, false)
+	expectBreaker(t, b, BreakerOpen)
+	if _, e = b.allow(); e == nil {
+		t.Fatalf("open breaker should not allow")
+	}
+	b.done(closedGen, true)
+	if s := b.State(); s.Status != BreakerOpen || s.ConsecutiveFailures != 1 {
+		t.Fatalf("result of an older generation should be ignored, got %+v", s)
+	}
+
+	// a request of the closed generation finishing in half open is no probe
+	b.lock.Lock()
+	b.state.NextProbe = time.Now()
+	b.lock.Unlock()
+	probeGen, e := b.allow()
+	if e != nil || probeGen == closedGen {
+		t.Fatalf("expect a probe of a new generation, got %d, %v", probeGen, e)
+	}
+	b.done(closedGen, false)
+	expectBreaker(t, b, BreakerHalfOpen)
+	if _, e = b.allow(); e == nil {
+		t.Fatalf("half open breaker should allow one probe at a time")
+	}
+	b.done(probeGen, true)
+	expectBreaker(t, b, BreakerClosed)
+}
+
+func TestBreakerNotCounted(t *testing.T) {
+	tt := newTestTransport()
+	b := NewBreaker("test", tt, BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour})
+	notCounted := notCountedByBreaker(context.Background())
+
+	tt.status["/part"] = http.StatusInternalServerError
+	roundTrip(b, notCounted, "/part")
+	roundTrip(b, notCounted, "/part")
+	if s := b.State(); s.Status != BreakerClosed || s.ConsecutiveFailures != 0 {
+		t.Fatalf("requests not counted should not change the breaker, got %+v", s)
+	}
+
+	roundTrip(b, context.Background(), "/")
+	expectBreaker(t, b, BreakerOpen)
+	if _, ok := roundTrip(b, notCounted, "/part").(*ErrBreakerOpen); !ok || tt.calls["/part"] != 2 {
+		t.Fatalf("requests not counted should fail fast while open, calls %d", tt.calls["/part"])
+	}
+}
@@ -1,27 +1,39 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
-	"github.com/caicloud/nirvana/log"
+	"golang.org/x/time/rate"
 
+	"github.com/caicloud/dashboard-admin/pkg/cache/notify"
 	"github.com/caicloud/dashboard-admin/pkg/config"
+	"github.com/caicloud/dashboard-admin/pkg/errors"
 )
 
-type Refresher interface {
-	Name() string
-	Refresh(client *http.Client, host string) error
-}
-
 type Cache struct {
 	cfg config.Config
-	clt *http.Client
 
 	CauthCache *CauthCache
 	DevopCache *DaCache
 	CargoCache *CargoCache
+
+	notifier notify.Notifier
+
+	runners []*refreshRunner
+
+	// per user limiters of on-demand refreshes, idle ones are evicted
+	triggerLimiters map[string]*triggerLimiter
+	triggerPruned   time.Time
+	triggerLock     sync.Mutex
+}
+
+type triggerLimiter struct {
+	*rate.Limiter
+	lastUsed time.Time
 }
 
 func NewCache(cfg *config.Config) (*Cache, error) {
@@ -31,65 +43,173 @@ func NewCache(cfg *config.Config) (*Cache, error) {
 	if e := cfg.Validate(); e != nil {
 		return nil, e
 	}
-	clt, e := NewHttpClient(time.Duration(cfg.TimeoutSecond) * time.Second)
+	cc, e := NewCauthCache(cfg.CauthPageSize)
 	if e != nil {
 		return nil, e
 	}
-	cc, e := NewCauthCache()
+	dc, e := NewDaCache(cfg.DevOpAdminConcurrency)
 	if e != nil {
 		return nil, e
 	}
-	dc, e := NewDaCache()
+	dc.SetTenants(cc.TenantIDs)
+	cac, e := NewCargoCache(cfg.CargoAdminConcurrency,
+		time.Duration(cfg.CargoAdminRepositorySecond)*time.Second)
 	if e != nil {
 		return nil, e
 	}
-	cac, e := NewCargoCache()
-	if e != nil {
-		return nil, e
-	}
-	return &Cache{
+	cac.SetTenants(cc.TenantIDs)
+	c := &Cache{
 		cfg:        *cfg,
-		clt:        clt,
 		CauthCache: cc,
 		DevopCache: dc,
 		CargoCache: cac,
-	}, nil
+
+		triggerLimiters: make(map[string]*triggerLimiter),
+	}
+	cauthClient, cauthBreaker, e := c.newUpstreamClient(CacheNameCauth, cfg.CauthTokenFile, cfg.CauthBasicAuthFile)
+	if e != nil {
+		return nil, e
+	}
+	devopClient, devopBreaker, e := c.newUpstreamClient(CacheNameDevopAdmin,
+		cfg.DevOpAdminTokenFile, cfg.DevOpAdminBasicAuthFile)
+	if e != nil {
+		return nil, e
+	}
+	cargoClient, cargoBreaker, e := c.newUpstreamClient(CacheNameCargo, cfg.CargoAdminTokenFile, cfg.CargoAdminBasicAuthFile)
+	if e != nil {
+		return nil, e
+	}
+	c.runners = []*refreshRunner{
+		newRefreshRunner(cauthClient, cauthBreaker, cfg.CauthHost, cc,
+			c.refreshConfig(cfg.CauthRefreshSecond), c.refreshed),
+		newRefreshRunner(devopClient, devopBreaker, cfg.DevOpAdminHost, dc,
+			c.refreshConfig(cfg.DevOpAdminRefreshSecond), c.refreshed),
+		newRefreshRunner(cargoClient, cargoBreaker, cfg.CargoAdminHost, cac,
+			c.refreshConfig(cfg.CargoAdminRefreshSecond), c.refreshed),
+	}
+	return c, nil
 }
 
-func (c *Cache) Run(stopCh chan struct{}) {
-	refreshTime := time.Duration(c.cfg.RefreshSecond) * time.Second
+// newUpstreamClient creates the client of upstream name, which fails fast
+// by its circuit breaker
+func (c *Cache) newUpstreamClient(name, tokenFile, basicAuthFile string) (*http.Client, *Breaker, error) {
+	clt, e := NewUpstreamClient(ClientConfig{
+		Timeout:       time.Duration(c.cfg.TimeoutSecond) * time.Second,
+		CAFile:        c.cfg.UpstreamCAFile,
+		CertFile:      c.cfg.UpstreamCertFile,
+		KeyFile:       c.cfg.UpstreamKeyFile,
+		TokenFile:     tokenFile,
+		BasicAuthFile: basicAuthFile,
+	})
+	if e != nil {
+		return nil, nil, fmt.Errorf("%s client, %v", name, e)
+	}
+	b := NewBreaker(name, clt.Transport, BreakerConfig{
+		FailureThreshold: c.cfg.BreakerFailureThreshold,
+		OpenTimeout:      time.Duration(c.cfg.BreakerOpenSecond) * time.Second,
+		SuccessThreshold: c.cfg.BreakerSuccessThreshold,
+	})
+	clt.Transport = b
+	return clt, b, nil
+}
 
-	go RunRefresher(c.clt, c.cfg.CauthHost, c.CauthCache, stopCh, refreshTime)
-	go RunRefresher(c.clt, c.cfg.DevOpAdminHost, c.DevopCache, stopCh, refreshTime)
-	go RunRefresher(c.clt, c.cfg.CargoAdminHost, c.CargoCache, stopCh, refreshTime)
+// refreshConfig of an upstream, refreshSecond 0 means the global one
+func (c *Cache) refreshConfig(refreshSecond int) RefreshConfig {
+	if refreshSecond == 0 {
+		refreshSecond = c.cfg.RefreshSecond
+	}
+	return RefreshConfig{
+		Interval:       time.Duration(refreshSecond) * time.Second,
+		InitialBackoff: time.Duration(c.cfg.RefreshInitialBackoffSecond) * time.Second,
+		MaxBackoff:     time.Duration(c.cfg.RefreshMaxBackoffSecond) * time.Second,
+		MaxStaleness:   time.Duration(c.cfg.RefreshMaxStalenessSecond) * time.Second,
+	}
+}
 
+func (c *Cache) Run(stopCh chan struct{}) {
+	for _, rr := range c.runners {
+		go rr.Run(stopCh)
+	}
 	<-stopCh
 }
 
-func RunRefresher(client *http.Client, host string, r Refresher, stopCh chan struct{}, refreshTime time.Duration) {
-	name := r.Name()
-	log.Infof("%s cache start in refresh time: %v", name, refreshTime)
-	r.Refresh(client, host)
-
-	tk := time.NewTicker(refreshTime)
-	for {
-		select {
-		case <-stopCh:
-			log.Warningf("%s cache stopped", name)
-			tk.Stop()
-			return
-		case <-tk.C:
-			start := time.Now()
-			log.Infof("%s cache refresh start", name)
-			e := r.Refresh(client, host)
-			cost := time.Now().Sub(start)
+// RefreshStates returns the refresh states of all the upstreams
+func (c *Cache) RefreshStates() []RefreshState {
+	re := make([]RefreshState, 0, len(c.runners))
+	for _, rr := range c.runners {
+		re = append(re, rr.State())
+	}
+	return re
+}
+
+// StaleUpstreams returns the ones of names whose data is stale, either not
+// refreshed in max staleness or never refreshed since restored from snapshot
+func (c *Cache) StaleUpstreams(names ...string) []string {
+	var re []string
+	for _, rr := range c.runners {
+		for _, name := range names {
+			if rr.r.Name() == name && rr.State().Stale {
+				re = append(re, name)
+			}
+		}
+	}
+	return re
+}
+
+// TriggerRefresh refreshes the upstream cache of slug at once, concurrent
+// triggers and the periodic refresh in flight share one refresh
+func (c *Cache) TriggerRefresh(ctx context.Context, slug string) (*RefreshResult, *errors.FormatError) {
+	for _, rr := range c.runners {
+		if rr.r.Slug() == slug {
+			re, e := rr.Trigger(ctx)
 			if e != nil {
-				log.Infof("%s cache refresh done in %v", name, cost)
-			} else {
-				log.Errorf("%s cache refresh failed in %v, %v", name, cost, e)
+				return nil, errors.NewError().SetErrorInternalServerError(e)
 			}
+			return re, nil
 		}
 	}
+	return nil, errors.NewError().SetErrorObjectNotFound(slug, fmt.Errorf("no upstream cache %s", slug))
+}
+
+// AllowTrigger checks the on-demand refresh rate of user
+func (c *Cache) AllowTrigger(user string) bool {
+	if c.cfg.RefreshTriggerQPS <= 0 {
+		return true
+	}
+	// a limiter idle for the time to refill its burst is the same as a new one
+	idle := time.Duration(float64(c.cfg.RefreshTriggerBurst) / c.cfg.RefreshTriggerQPS * float64(time.Second))
+	now := time.Now()
+	c.triggerLock.Lock()
+	if now.Sub(c.triggerPruned) > idle {
+		c.triggerPruned = now
+		for u, l := range c.triggerLimiters {
+			if now.Sub(l.lastUsed) > idle {
+				delete(c.triggerLimiters, u)
+			}
+		}
+	}
+	l, ok := c.triggerLimiters[user]
+	if !ok {
+		l = &triggerLimiter{Limiter: rate.NewLimiter(rate.Limit(c.cfg.RefreshTriggerQPS), c.cfg.RefreshTriggerBurst)}
+		c.triggerLimiters[user] = l
+	}
+	l.lastUsed = now
+	c.triggerLock.Unlock()
+	return l.Allow()
+}
+
+// UnavailableUpstreams returns the ones of names whose circuit breaker is not
+// closed, their data can not be refreshed for now
+func (c *Cache) UnavailableUpstreams(names ...string) []string {
+	var re []string
+	for _, rr := range c.runners {
+		for _, name := range names {
+			if rr.r.Name() == name && rr.breaker != nil && rr.breaker.State().Status != BreakerClosed {
+				re = append(re, name)
+			}
+		}
+	}
+	return re
 }
 
 func readAllErrorsFromChan(ec chan error) []error {
@@ -103,3 +223,17 @@ func readAllErrorsFromChan(ec chan error) []error {
 	}
 	return errs
 }
+
+// upstreamErrorf is fmt.Errorf which keeps the first FormatError of errs, so
+// the upstream reason is not lost when errors are aggregated
+func upstreamErrorf(errs []error, format string, a ...interface{}) error {
+	e := fmt.Errorf(format, a...)
+	for _, err := range errs {
+		if fe, ok := errors.GetFormatError(err); ok {
+			re := *fe
+			re.SetRawError(e)
+			return &re
+		}
+	}
+	return e
+}
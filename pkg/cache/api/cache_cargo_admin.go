@@ -1,44 +1,314 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/caicloud/nirvana/log"
+
+	"github.com/caicloud/dashboard-admin/pkg/errors"
 )
 
 const (
 	CacheNameCargo = "cargo admin"
+	CacheSlugCargo = "cargo-admin"
 )
 
 type CargoCache struct {
+	// max concurrent repository lists in a refresh
+	concurrency int
+	// min time between repository lists of a project, 0 means every refresh
+	repositoryInterval time.Duration
+	// tenants whose projects are listed, nil means listing without tenant
+	tenants func() (ids []string, known bool)
+
 	lock       sync.RWMutex
 	registries map[string]*Registry
+	// registry:project:detail
+	projects map[string]map[string]*ProjectDetail
+	synced   bool
+	// restored from a snapshot before the first refresh
+	restored bool
 }
 
-func NewCargoCache() (*CargoCache, error) {
+type ProjectDetail struct {
+	Registry string
+	Project  *Project
+	// tenants which see the project, empty if listed without tenant
+	Tenants      []string `json:",omitempty"`
+	Repositories []Repository
+	// when the repositories were listed
+	RepositoriesTime time.Time
+	// projects or repositories failed to refresh, the last known ones are kept
+	Stale     bool
+	LastError string `json:",omitempty"`
+}
+
+func NewCargoCache(concurrency int, repositoryInterval time.Duration) (*CargoCache, error) {
+	if concurrency < 1 {
+		return nil, fmt.Errorf("illegal cargo admin concurrency %d", concurrency)
+	}
+	if repositoryInterval < 0 {
+		return nil, fmt.Errorf("illegal cargo admin repository interval %v", repositoryInterval)
+	}
 	c := &CargoCache{
-		registries: make(map[string]*Registry),
+		concurrency:        concurrency,
+		repositoryInterval: repositoryInterval,
+		registries:         make(map[string]*Registry),
+		projects:           make(map[string]map[string]*ProjectDetail),
 	}
 	return c, nil
 }
 
+// SetTenants must be called before the cache runs
+func (c *CargoCache) SetTenants(tenants func() (ids []string, known bool)) {
+	c.tenants = tenants
+}
+
 func (c *CargoCache) Name() string {
 	return CacheNameCargo
 }
 
-func (c *CargoCache) Refresh(client *http.Client, host string) error {
+func (c *CargoCache) Slug() string {
+	return CacheSlugCargo
+}
+
+// Refresh lists the registries, then the projects of every registry and the
+// repositories of the projects not listed in the repository interval. Failed
+// projects and repositories keep the last known ones as stale, it fails only
+// if the registries or all the projects fail to list.
+func (c *CargoCache) Refresh(client *http.Client, host string) (changed bool, e error) {
+	// the keys and tenants of projects never turn from no tenant into tenants
+	tenants := []string{""}
+	if c.tenants != nil {
+		ids, known := c.tenants()
+		if !known {
+			return false, errors.NewError().SetErrorUpstreamNotSynced(CacheNameCauth)
+		}
+		tenants = ids
+	}
 	registries, e := GetRegistriesMap(client, host)
 	if e != nil {
 		log.Errorf("refresh list registry failed, %v", e)
-		return e
+		return false, e
+	}
+	c.lock.RLock()
+	// nil if not changed
+	changed = registries != nil || !c.synced
+	if registries == nil {
+		registries = c.registries
+	}
+	old := c.projects
+	c.lock.RUnlock()
+
+	var (
+		projects = make(map[string]map[string]*ProjectDetail, len(registries))
+		pds      []*ProjectDetail
+		errs     []error
+	)
+	for registry := range registries {
+		m, modified, e := listProjectDetails(client, host, registry, tenants, old[registry])
+		changed = changed || modified || e != nil
+		if e != nil {
+			errs = append(errs, e)
+			log.Errorf("refresh list project in registry %v failed, %v", registry, e)
+			m = make(map[string]*ProjectDetail, len(old[registry]))
+			for name, pd := range old[registry] {
+				stale := *pd
+				stale.Stale, stale.LastError = true, e.Error()
+				m[name] = &stale
+			}
+		} else {
+			for _, pd := range m {
+				// of the tenants failed to list projects
+				if !pd.Stale {
+					pds = append(pds, pd)
+				}
+			}
+		}
+		projects[registry] = m
 	}
+	changed = c.listRepositories(client, host, pds, old) || changed || hasStaleProject(old)
 
 	c.lock.Lock()
 	c.registries = registries
+	c.projects = projects
+	c.synced = true
 	c.lock.Unlock()
 
-	return nil
+	if len(errs) > 0 {
+		if len(errs) == len(registries) {
+			return changed, upstreamErrorf(errs, "failed %d/%d, %v", len(errs), len(registries), errs)
+		}
+		log.Warningf("refresh projects failed %d/%d, last known ones are kept, %v", len(errs), len(registries), errs)
+	}
+	return changed, nil
+}
+
+// hasStaleProject tells if any of projects is stale
+func hasStaleProject(projects map[string]map[string]*ProjectDetail) bool {
+	for _, m := range projects {
+		for _, pd := range m {
+			if pd.Stale {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// seenBy tells if tenant sees the project
+func (pd *ProjectDetail) seenBy(tenant string) bool {
+	for _, t := range pd.Tenants {
+		if t == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// listProjectDetails lists the projects of registry seen by every tenant,
+// modified is false if none of the lists is changed. The last known projects
+// in old of the tenants failed are kept, as stale if no other tenant sees
+// them, it fails only if all the tenants fail.
+func listProjectDetails(client *http.Client, host, registry string, tenants []string,
+	old map[string]*ProjectDetail) (re map[string]*ProjectDetail, modified bool, e error) {
+	re = make(map[string]*ProjectDetail)
+	failed := make(map[string]error)
+	var errs []error
+	for _, tenant := range tenants {
+		list, listModified, e := ListProjects(client, host, tenant, registry)
+		if e != nil {
+			e = upstreamErrorf([]error{e}, "tenant %s, %v", tenant, e)
+			errs = append(errs, e)
+			failed[tenant] = e
+			log.Errorf("refresh list project in registry %v of tenant %s failed, %v", registry, tenant, e)
+			continue
+		}
+		modified = modified || listModified
+		for i := range list.Items {
+			p := &list.Items[i]
+			if p.Metadata == nil {
+				continue
+			}
+			pd, ok := re[p.Metadata.Name]
+			if !ok {
+				pd = &ProjectDetail{Registry: registry, Project: p}
+				re[p.Metadata.Name] = pd
+			}
+			if len(tenant) > 0 {
+				pd.Tenants = append(pd.Tenants, tenant)
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return re, modified, nil
+	}
+	if len(errs) == len(tenants) {
+		return nil, false, upstreamErrorf(errs, "failed %d/%d tenants, %v", len(errs), len(tenants), errs)
+	}
+	for _, tenant := range tenants {
+		e, ok := failed[tenant]
+		if !ok {
+			continue
+		}
+		for name, last := range old {
+			if !last.seenBy(tenant) {
+				continue
+			}
+			pd, ok := re[name]
+			if !ok {
+				stale := *last
+				stale.Tenants = nil
+				stale.Stale, stale.LastError = true, e.Error()
+				pd = &stale
+				re[name] = pd
+			}
+			pd.Tenants = append(pd.Tenants, tenant)
+		}
+	}
+	return re, true, nil
+}
+
+// listRepositories lists the repositories of pds concurrently, the ones
+// listed in the repository interval keep the last known repositories in old
+// without requests, so do the failed ones as stale. Once a list finds the
+// upstream unavailable, the projects left are not requested but kept stale.
+// It returns false if none of the lists is changed.
+func (c *CargoCache) listRepositories(client *http.Client, host string, pds []*ProjectDetail,
+	old map[string]map[string]*ProjectDetail) bool {
+	now := time.Now()
+	requested := make([]*ProjectDetail, 0, len(pds))
+	for _, pd := range pds {
+		last := old[pd.Registry][pd.Project.Metadata.Name]
+		if last != nil && !last.Stale && now.Sub(last.RepositoriesTime) < c.repositoryInterval {
+			pd.Repositories, pd.RepositoriesTime = last.Repositories, last.RepositoriesTime
+			continue
+		}
+		requested = append(requested, pd)
+	}
+
+	mc := make(chan bool, len(requested))
+	jobs := make(chan *ProjectDetail)
+	wg := sync.WaitGroup{}
+	// set once the upstream is found unavailable, the projects left are not
+	// requested but kept stale
+	var (
+		unavailable     error
+		unavailableLock sync.Mutex
+	)
+	for i := 0; i < c.concurrency && i < len(requested); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pd := range jobs {
+				tenant := ""
+				if len(pd.Tenants) > 0 {
+					tenant = pd.Tenants[0]
+				}
+				registry, name := pd.Registry, pd.Project.Metadata.Name
+				unavailableLock.Lock()
+				e := unavailable
+				unavailableLock.Unlock()
+				var (
+					list     *RepositoryList
+					modified bool
+				)
+				if e == nil {
+					list, modified, e = ListRepositories(client, host, tenant, registry, name)
+					if fe, ok := errors.GetFormatError(e); ok && fe.Reason == errors.ErrorReasonUpstreamUnavailable {
+						unavailableLock.Lock()
+						unavailable = e
+						unavailableLock.Unlock()
+					}
+				}
+				if e != nil {
+					mc <- true
+					pd.Stale, pd.LastError = true, e.Error()
+					if last := old[registry][name]; last != nil {
+						pd.Repositories, pd.RepositoriesTime = last.Repositories, last.RepositoriesTime
+					}
+					log.Errorf("refresh list repository in project %v/%v failed, %v", registry, name, e)
+					continue
+				}
+				pd.Repositories, pd.RepositoriesTime = list.Items, now
+				mc <- modified
+			}
+		}()
+	}
+	for _, pd := range requested {
+		jobs <- pd
+	}
+	close(jobs)
+	wg.Wait()
+
+	modified := false
+	for len(mc) > 0 {
+		modified = <-mc || modified
+	}
+	return modified
 }
 
 func (c *CargoCache) GetRegistriesMap() map[string]*Registry {
@@ -46,3 +316,10 @@ func (c *CargoCache) GetRegistriesMap() map[string]*Registry {
 	defer c.lock.RUnlock()
 	return c.registries
 }
+
+// GetProjectsMap returns the projects of registry by name
+func (c *CargoCache) GetProjectsMap(registry string) map[string]*ProjectDetail {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.projects[registry]
+}
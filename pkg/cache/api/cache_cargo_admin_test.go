@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caicloud/dashboard-admin/pkg/constants"
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+func newTestProjectList(names ...string) *ProjectList {
+	re := new(ProjectList)
+	for _, name := range names {
+		re.Items = append(re.Items, Project{Metadata: &ProjectMetadata{Name: name}})
+	}
+	return re
+}
+
+func TestCargoRefreshTenantsAndRepositoryInterval(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		down  = map[string]bool{}
+		calls = map[string]int{}
+	)
+	projects := map[string]*ProjectList{"a": newTestProjectList("p1", "p2"), "b": newTestProjectList("p2", "p3")}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		tenant := r.Header.Get(constants.ParameterXTenant)
+		calls[r.URL.Path]++
+		switch {
+		case r.URL.Path == "/api/v2/registries":
+			json.NewEncoder(w).Encode(&RegistryList{Items: []Registry{{Metadata: &RegistryMetadata{Name: "r"}}}})
+		case down[tenant]:
+			w.WriteHeader(http.StatusInternalServerError)
+		case strings.HasSuffix(r.URL.Path, "/projects"):
+			json.NewEncoder(w).Encode(projects[tenant])
+		default:
+			json.NewEncoder(w).Encode(&RepositoryList{Items: []Repository{{Metadata: &RepositoryMetadata{Name: "repo"}}}})
+		}
+	}))
+	defer srv.Close()
+	c, _ := NewCargoCache(1, time.Hour)
+	known := false
+	c.SetTenants(func() ([]string, bool) {
+		return []string{"a", "b"}, known
+	})
+	repositoryCalls := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		re := 0
+		for _, p := range []string{"p1", "p2", "p3"} {
+			re += calls["/api/v2/registries/r/projects/"+p+"/repositories"]
+		}
+		return re
+	}
+
+	// no project is listed without tenant before the tenants are known
+	_, e := c.Refresh(srv.Client(), srv.URL)
+	if fe, ok := e.(*errors.FormatError); !ok || fe.Reason != errors.ErrorReasonUpstreamNotSynced || len(calls) > 0 {
+		t.Fatalf("expect not synced without requests, got %v, %v", e, calls)
+	}
+
+	known = true
+	for i := 0; i < 2; i++ {
+		if _, e = c.Refresh(srv.Client(), srv.URL); e != nil {
+			t.Fatalf("refresh failed, %v", e)
+		}
+	}
+	if n := repositoryCalls(); n != 3 {
+		t.Fatalf("expect repositories listed once in the interval, got %d requests", n)
+	}
+	if pd := c.GetProjectsMap("r")["p2"]; pd == nil || !reflect.DeepEqual(pd.Tenants, []string{"a", "b"}) || len(pd.Repositories) != 1 {
+		t.Fatalf("expect p2 seen by a and b with its repositories, got %+v", pd)
+	}
+
+	lock.Lock()
+	down["b"] = true
+	lock.Unlock()
+	c.repositoryInterval = 0
+	if _, e = c.Refresh(srv.Client(), srv.URL); e != nil {
+		t.Fatalf("refresh should not fail with a tenant left, %v", e)
+	}
+	if n := repositoryCalls(); n != 5 {
+		t.Fatalf("expect repositories of p1 and p2 listed again, got %d requests", n-3)
+	}
+	pm := c.GetProjectsMap("r")
+	if pd := pm["p2"]; pd == nil || pd.Stale || !reflect.DeepEqual(pd.Tenants, []string{"a", "b"}) {
+		t.Fatalf("expect p2 still seen by a and b, got %+v", pd)
+	}
+	if pd := pm["p3"]; pd == nil || !pd.Stale || !reflect.DeepEqual(pd.Tenants, []string{"b"}) || len(pd.Repositories) != 1 {
+		t.Fatalf("expect p3 of b kept as stale, got %+v", pd)
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"sort"
 	"sync"
 
 	"github.com/caicloud/nirvana/log"
@@ -10,22 +11,31 @@ import (
 
 const (
 	CacheNameCauth = "cauth"
+	CacheSlugCauth = "cauth"
 )
 
 type CauthCache struct {
+	// items per list request
+	pageSize int
+
 	lock    sync.RWMutex
 	users   map[string]*User
 	teams   map[string]*Team
 	tenants map[string]*Tenant
 	roles   map[string]*Role
+	synced  bool
 }
 
-func NewCauthCache() (*CauthCache, error) {
+func NewCauthCache(pageSize int) (*CauthCache, error) {
+	if pageSize < 1 {
+		return nil, fmt.Errorf("illegal cauth page size %d", pageSize)
+	}
 	c := &CauthCache{
-		users:   make(map[string]*User),
-		teams:   make(map[string]*Team),
-		tenants: make(map[string]*Tenant),
-		roles:   make(map[string]*Role),
+		pageSize: pageSize,
+		users:    make(map[string]*User),
+		teams:    make(map[string]*Team),
+		tenants:  make(map[string]*Tenant),
+		roles:    make(map[string]*Role),
 	}
 	return c, nil
 }
@@ -34,7 +44,11 @@ func (c *CauthCache) Name() string {
 	return CacheNameCauth
 }
 
-func (c *CauthCache) Refresh(client *http.Client, host string) error {
+func (c *CauthCache) Slug() string {
+	return CacheSlugCauth
+}
+
+func (c *CauthCache) Refresh(client *http.Client, host string) (changed bool, e error) {
 	const (
 		mapNum = 4
 	)
@@ -49,7 +63,7 @@ func (c *CauthCache) Refresh(client *http.Client, host string) error {
 	wg.Add(mapNum)
 	go func() {
 		var e error
-		users, e = GetUsersMap(client, host)
+		users, e = GetUsersMap(client, host, c.pageSize)
 		if e != nil {
 			log.Errorf("refresh get user map failed, %v", e)
 			ec <- e
@@ -58,7 +72,7 @@ func (c *CauthCache) Refresh(client *http.Client, host string) error {
 	}()
 	go func() {
 		var e error
-		teams, e = GetTeamsMap(client, host)
+		teams, e = GetTeamsMap(client, host, c.pageSize)
 		if e != nil {
 			log.Errorf("refresh get team map failed, %v", e)
 			ec <- e
@@ -67,7 +81,7 @@ func (c *CauthCache) Refresh(client *http.Client, host string) error {
 	}()
 	go func() {
 		var e error
-		tenants, e = GetTenantMap(client, host)
+		tenants, e = GetTenantMap(client, host, c.pageSize)
 		if e != nil {
 			log.Errorf("refresh get tenant map failed, %v", e)
 			ec <- e
@@ -76,7 +90,7 @@ func (c *CauthCache) Refresh(client *http.Client, host string) error {
 	}()
 	go func() {
 		var e error
-		roles, e = GetRolesMap(client, host)
+		roles, e = GetRolesMap(client, host, c.pageSize)
 		if e != nil {
 			log.Errorf("refresh get role map failed, %v", e)
 			ec <- e
@@ -100,12 +114,14 @@ func (c *CauthCache) Refresh(client *http.Client, host string) error {
 	if roles != nil {
 		c.roles = roles
 	}
+	changed = users != nil || teams != nil || tenants != nil || roles != nil
 
 	if len(ec) > 0 {
 		errs := readAllErrorsFromChan(ec)
-		return fmt.Errorf("failed %d/%d, %v", len(errs), mapNum, errs)
+		return changed, upstreamErrorf(errs, "failed %d/%d, %v", len(errs), mapNum, errs)
 	}
-	return nil
+	c.synced = true
+	return changed, nil
 }
 
 func (c *CauthCache) GetUsersMap() map[string]*User {
@@ -123,6 +139,20 @@ func (c *CauthCache) GetTenantsMap() map[string]*Tenant {
 	defer c.lock.RUnlock()
 	return c.tenants
 }
+
+// TenantIDs returns the ids of all the tenants sorted, known is false if the
+// tenants are neither refreshed nor restored yet
+func (c *CauthCache) TenantIDs() (ids []string, known bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	ids = make([]string, 0, len(c.tenants))
+	for id := range c.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, c.synced || len(c.tenants) > 0
+}
+
 func (c *CauthCache) GetRolesMap() map[string]*Role {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
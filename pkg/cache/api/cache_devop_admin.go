@@ -6,69 +6,233 @@ import (
 	"sync"
 
 	"github.com/caicloud/nirvana/log"
+
+	"github.com/caicloud/dashboard-admin/pkg/errors"
 )
 
 const (
 	CacheNameDevopAdmin = "devop admin"
+	CacheSlugDevopAdmin = "devops-admin"
 )
 
 type DaCache struct {
-	lock  sync.RWMutex
-	wsMap map[string]*WorkspaceDetail
+	// max concurrent pipeline lists in a refresh
+	concurrency int
+	// tenants whose workspaces are listed, nil means listing without tenant
+	tenants func() (ids []string, known bool)
+
+	lock   sync.RWMutex
+	wsMap  map[string]*WorkspaceDetail
+	synced bool
 }
 
 type WorkspaceDetail struct {
+	// empty if listed without tenant
+	Tenant    string `json:",omitempty"`
 	Workspace *Workspace
 	Pipelines []Pipeline
+	// pipelines failed to refresh, the last known ones are kept
+	Stale     bool
+	LastError string `json:",omitempty"`
+}
+
+// WorkspaceKey is the key of a workspace in the map of DaCache, names are
+// only unique in a tenant
+func WorkspaceKey(tenant, workspace string) string {
+	if len(tenant) == 0 {
+		return workspace
+	}
+	return tenant + "/" + workspace
 }
 
-func NewDaCache() (*DaCache, error) {
+func NewDaCache(concurrency int) (*DaCache, error) {
+	if concurrency < 1 {
+		return nil, fmt.Errorf("illegal devops admin concurrency %d", concurrency)
+	}
 	c := &DaCache{
-		wsMap: make(map[string]*WorkspaceDetail),
+		concurrency: concurrency,
+		wsMap:       make(map[string]*WorkspaceDetail),
 	}
 	return c, nil
 }
 
+// SetTenants must be called before the cache runs
+func (c *DaCache) SetTenants(tenants func() ([]string, bool)) {
+	c.tenants = tenants
+}
+
 func (c *DaCache) Name() string {
 	return CacheNameDevopAdmin
 }
 
-func (c *DaCache) Refresh(client *http.Client, host string) error {
-	workspaces, e := ListWorkspaces(client, host)
+func (c *DaCache) Slug() string {
+	return CacheSlugDevopAdmin
+}
+
+// Refresh lists the pipelines of every workspace, workspaces failed keep
+// the last known pipelines as stale, and the disappeared ones are dropped.
+// Once a list finds the upstream unavailable, the workspaces left are not
+// requested but kept stale. It fails only if the workspaces of all tenants
+// or all the pipelines fail to list. The map is kept if nothing is changed.
+func (c *DaCache) Refresh(client *http.Client, host string) (changed bool, e error) {
+	wds, modified, e := c.listWorkspaces(client, host)
 	if e != nil {
 		log.Errorf("refresh list workspace failed, %v", e)
-		return e
+		return false, e
 	}
-	wsMap := make(map[string]*WorkspaceDetail)
-	wds := make([]WorkspaceDetail, len(workspaces.Items))
+	ec := make(chan error, len(wds))
+	mc := make(chan bool, len(wds))
+	jobs := make(chan *WorkspaceDetail)
 	wg := sync.WaitGroup{}
-	ec := make(chan error, len(workspaces.Items))
-	for i := range workspaces.Items {
-		wds[i].Workspace = &workspaces.Items[i]
-		wsMap[wds[i].Workspace.Name] = &wds[i]
+	// set once the upstream is found unavailable, the workspaces left are not
+	// requested but kept stale
+	var (
+		unavailable     error
+		unavailableLock sync.Mutex
+	)
+	for i := 0; i < c.concurrency && i < len(wds); i++ {
 		wg.Add(1)
-		go func(wd *WorkspaceDetail) {
-			pipelineList, e := ListPipelines(client, host, wd.Workspace.Name)
-			if e != nil {
-				ec <- e
-				log.Errorf("refresh list pipeline in workspace %v failed, %v", wd.Workspace.Name, e)
-			} else {
+		go func() {
+			defer wg.Done()
+			for wd := range jobs {
+				unavailableLock.Lock()
+				e := unavailable
+				unavailableLock.Unlock()
+				var (
+					pipelineList      *PipelineList
+					pipelinesModified bool
+				)
+				if e == nil {
+					pipelineList, pipelinesModified, e = ListPipelines(client, host, wd.Tenant, wd.Workspace.Name)
+					if fe, ok := errors.GetFormatError(e); ok && fe.Reason == errors.ErrorReasonUpstreamUnavailable {
+						unavailableLock.Lock()
+						unavailable = e
+						unavailableLock.Unlock()
+					}
+				}
+				if e != nil {
+					ec <- e
+					wd.Stale = true
+					wd.LastError = e.Error()
+					log.Errorf("refresh list pipeline in workspace %v failed, %v",
+						WorkspaceKey(wd.Tenant, wd.Workspace.Name), e)
+					continue
+				}
 				wd.Pipelines = pipelineList.Items
+				mc <- pipelinesModified
 			}
-			wg.Done()
-		}(&wds[i])
+		}()
 	}
+	requested := 0
+	for i := range wds {
+		// of the tenants failed to list workspaces
+		if wds[i].Stale {
+			continue
+		}
+		requested++
+		jobs <- &wds[i]
+	}
+	close(jobs)
 	wg.Wait()
 
-	if len(ec) > 0 {
-		errs := readAllErrorsFromChan(ec)
-		return fmt.Errorf("failed %d/%d, %v", len(errs), len(wds), errs)
+	for len(mc) > 0 {
+		modified = <-mc || modified
 	}
 
 	c.lock.Lock()
+	if !modified && len(ec) == 0 && c.synced && !c.hasStale() {
+		c.lock.Unlock()
+		return false, nil
+	}
+	wsMap := make(map[string]*WorkspaceDetail, len(wds))
+	for i := range wds {
+		wd := &wds[i]
+		key := WorkspaceKey(wd.Tenant, wd.Workspace.Name)
+		if old := c.wsMap[key]; wd.Stale && old != nil {
+			wd.Pipelines = old.Pipelines
+		}
+		wsMap[key] = wd
+	}
 	c.wsMap = wsMap
+	c.synced = true
 	c.lock.Unlock()
-	return nil
+
+	if len(ec) > 0 {
+		errs := readAllErrorsFromChan(ec)
+		if len(errs) == requested {
+			return true, upstreamErrorf(errs, "failed %d/%d, %v", len(errs), requested, errs)
+		}
+		log.Warningf("refresh pipelines failed %d/%d, last known ones are kept, %v", len(errs), requested, errs)
+	}
+	return true, nil
+}
+
+// listWorkspaces lists the workspaces of every tenant, or all without tenant
+// if the cache has no tenants. It waits for the tenants to be known, so the
+// keys never turn from names into tenant ones. Tenants failed keep their last
+// known workspaces as stale, it fails only if all of them fail.
+func (c *DaCache) listWorkspaces(client *http.Client, host string) ([]WorkspaceDetail, bool, error) {
+	tenants := []string{""}
+	if c.tenants != nil {
+		ids, known := c.tenants()
+		if !known {
+			return nil, false, errors.NewError().SetErrorUpstreamNotSynced(CacheNameCauth)
+		}
+		tenants = ids
+	}
+	var (
+		re       []WorkspaceDetail
+		modified bool
+		errs     []error
+	)
+	for _, tenant := range tenants {
+		workspaces, wsModified, e := ListWorkspaces(client, host, tenant)
+		if e != nil {
+			e = upstreamErrorf([]error{e}, "tenant %s, %v", tenant, e)
+			errs = append(errs, e)
+			log.Errorf("refresh list workspace of tenant %s failed, %v", tenant, e)
+			re = append(re, c.staleWorkspaces(tenant, e)...)
+			continue
+		}
+		modified = modified || wsModified
+		// the items may be shared with the last refresh, so are never modified
+		for i := range workspaces.Items {
+			re = append(re, WorkspaceDetail{Tenant: tenant, Workspace: &workspaces.Items[i]})
+		}
+	}
+	if len(errs) > 0 {
+		if len(errs) == len(tenants) {
+			return nil, false, upstreamErrorf(errs, "failed %d/%d tenants, %v", len(errs), len(tenants), errs)
+		}
+		modified = true
+	}
+	return re, modified, nil
+}
+
+// staleWorkspaces returns the last known workspaces of tenant as stale
+func (c *DaCache) staleWorkspaces(tenant string, e error) []WorkspaceDetail {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	var re []WorkspaceDetail
+	for _, wd := range c.wsMap {
+		if wd.Tenant != tenant {
+			continue
+		}
+		stale := *wd
+		stale.Stale, stale.LastError = true, e.Error()
+		re = append(re, stale)
+	}
+	return re
+}
+
+// hasStale must be called with lock held
+func (c *DaCache) hasStale() bool {
+	for _, wd := range c.wsMap {
+		if wd.Stale {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *DaCache) GetWorkspaceMap() map[string]*WorkspaceDetail {
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/caicloud/dashboard-admin/pkg/constants"
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+func TestDevopRefreshStopsWhenUnavailable(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		down  bool
+		calls = map[string]int{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		calls[r.URL.Path]++
+		switch {
+		case r.URL.Path == "/api/v1/workspaces":
+			json.NewEncoder(w).Encode(&WorkspaceList{Items: []Workspace{{Name: "a"}, {Name: "b"}, {Name: "c"}}})
+		case down:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			json.NewEncoder(w).Encode(&PipelineList{Items: []Pipeline{{Name: "p"}}})
+		}
+	}))
+	defer srv.Close()
+	c, _ := NewDaCache(1)
+	if _, e := c.Refresh(srv.Client(), srv.URL); e != nil {
+		t.Fatalf("refresh failed, %v", e)
+	}
+
+	lock.Lock()
+	down = true
+	lock.Unlock()
+	if _, e := c.Refresh(srv.Client(), srv.URL); e == nil {
+		t.Fatalf("refresh should fail when all workspaces fail")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	requested := 0
+	for _, name := range []string{"a", "b", "c"} {
+		requested += calls["/api/v1/workspaces/"+name+"/pipelines"]
+	}
+	if requested != 4 {
+		t.Fatalf("expect only the first workspace requested after the upstream is down, got %d requests", requested-3)
+	}
+	for key, wd := range c.GetWorkspaceMap() {
+		if !wd.Stale || len(wd.Pipelines) != 1 {
+			t.Fatalf("workspace %s should keep its pipelines as stale, got %+v", key, wd)
+		}
+	}
+}
+
+func TestDevopRefreshTenantsPartial(t *testing.T) {
+	var (
+		lock  sync.Mutex
+		down  = map[string]bool{}
+		calls = map[string]int{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		tenant := r.Header.Get(constants.ParameterXTenant)
+		calls[tenant+r.URL.Path]++
+		switch {
+		case down[tenant]:
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.URL.Path == "/api/v1/workspaces":
+			json.NewEncoder(w).Encode(&WorkspaceList{Items: []Workspace{{Name: "w"}}})
+		default:
+			json.NewEncoder(w).Encode(&PipelineList{Items: []Pipeline{{Name: "p"}}})
+		}
+	}))
+	defer srv.Close()
+	c, _ := NewDaCache(1)
+	known := false
+	c.SetTenants(func() ([]string, bool) {
+		return []string{"a", "b"}, known
+	})
+
+	// no workspace is listed without tenant before the tenants are known
+	_, e := c.Refresh(srv.Client(), srv.URL)
+	if fe, ok := e.(*errors.FormatError); !ok || fe.Reason != errors.ErrorReasonUpstreamNotSynced || len(calls) > 0 {
+		t.Fatalf("expect not synced without requests, got %v, %v", e, calls)
+	}
+
+	known = true
+	if _, e = c.Refresh(srv.Client(), srv.URL); e != nil {
+		t.Fatalf("refresh failed, %v", e)
+	}
+	lock.Lock()
+	down["b"] = true
+	lock.Unlock()
+	if _, e = c.Refresh(srv.Client(), srv.URL); e != nil {
+		t.Fatalf("refresh should not fail with a tenant left, %v", e)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if n := calls["b/api/v1/workspaces/w/pipelines"]; n != 1 {
+		t.Fatalf("expect the pipelines of the failed tenant not requested again, got %d requests", n)
+	}
+	wsMap := c.GetWorkspaceMap()
+	if len(wsMap) != 2 {
+		t.Fatalf("expect the workspaces of both tenants, got %+v", wsMap)
+	}
+	if wd := wsMap[WorkspaceKey("a", "w")]; wd == nil || wd.Stale {
+		t.Fatalf("expect the workspace of a refreshed, got %+v", wd)
+	}
+	if wd := wsMap[WorkspaceKey("b", "w")]; wd == nil || !wd.Stale || len(wd.LastError) == 0 || len(wd.Pipelines) != 1 {
+		t.Fatalf("expect the workspace of b kept as stale, got %+v", wd)
+	}
+}
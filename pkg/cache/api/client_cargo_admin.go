@@ -1,31 +1,37 @@
 package api
 
 import (
+	"context"
 	"net/http"
-	"path"
 )
 
 const (
 	cargoUrlBase    = "api"
 	cargoApiVersion = "v2"
 
-	registriesListPath = "registries"
-	registriesListCode = 200
+	registriesListPath   = "registries"
+	projectsListPath     = "projects"
+	repositoriesListPath = "repositories"
+
+	registriesListCode   = 200
+	projectsListCode     = 200
+	repositoriesListCode = 200
 )
 
-func ListRegistries(c *http.Client, caHost string) (*RegistryList, error) {
-	re := new(RegistryList)
-	url := "http://" + path.Join(caHost, cargoUrlBase, cargoApiVersion, registriesListPath)
-	e := doGet(c, url, registriesListCode, re)
+func ListRegistries(c *http.Client, caHost string) (re *RegistryList, modified bool, e error) {
+	re = new(RegistryList)
+	url := upstreamURL(caHost, cargoUrlBase, cargoApiVersion, registriesListPath)
+	modified, e = doGet(c, CacheNameCargo, url, registriesListCode, re)
 	if e != nil {
-		return nil, e
+		return nil, false, e
 	}
-	return re, nil
+	return re, modified, nil
 }
 
+// GetRegistriesMap returns nil map if no registry is changed since the last call
 func GetRegistriesMap(c *http.Client, dexHost string) (map[string]*Registry, error) {
-	registryList, e := ListRegistries(c, dexHost)
-	if e != nil {
+	registryList, modified, e := ListRegistries(c, dexHost)
+	if e != nil || !modified {
 		return nil, e
 	}
 	m := make(map[string]*Registry, len(registryList.Items))
@@ -35,3 +41,28 @@ func GetRegistriesMap(c *http.Client, dexHost string) (map[string]*Registry, err
 	}
 	return m, nil
 }
+
+// ListProjects lists the projects of registry which tenant can see, empty
+// tenant means without X-Tenant header
+func ListProjects(c *http.Client, caHost, tenant, registry string) (re *ProjectList, modified bool, e error) {
+	re = new(ProjectList)
+	url := upstreamURL(caHost, cargoUrlBase, cargoApiVersion, registriesListPath, registry, projectsListPath)
+	modified, e = doGetWithHeader(c, CacheNameCargo, url, tenantHeader(tenant), projectsListCode, re)
+	if e != nil {
+		return nil, false, e
+	}
+	return re, modified, nil
+}
+
+func ListRepositories(c *http.Client, caHost, tenant, registry, project string) (re *RepositoryList, modified bool, e error) {
+	re = new(RepositoryList)
+	url := upstreamURL(caHost, cargoUrlBase, cargoApiVersion, registriesListPath, registry,
+		projectsListPath, project, repositoriesListPath)
+	// a project failing is stale alone, so is not counted by the breaker
+	modified, e = doGetWithContext(notCountedByBreaker(context.Background()), c, CacheNameCargo, url,
+		tenantHeader(tenant), repositoriesListCode, re)
+	if e != nil {
+		return nil, false, e
+	}
+	return re, modified, nil
+}
@@ -2,7 +2,6 @@ package api
 
 import (
 	"net/http"
-	"path"
 )
 
 const (
@@ -20,90 +19,161 @@ const (
 	rolesListCode  = 200
 )
 
-func ListUsers(c *http.Client, dexHost string) (*UserList, error) {
-	re := new(UserList)
-	url := "http://" + path.Join(dexHost, dexUrlBase, dexApiVersion, usersListPath)
-	e := doGet(c, url, usersListCode, re)
-	if e != nil {
-		return nil, e
+func dexListURL(dexHost, listPath string, opts interface{}) (string, http.Header) {
+	query, header := encodeListOptions(opts)
+	url := upstreamURL(dexHost, dexUrlBase, dexApiVersion, listPath)
+	if len(query) > 0 {
+		url += "?" + query.Encode()
 	}
-	return re, nil
+	return url, header
 }
 
-func ListTenant(c *http.Client, dexHost string) (*TenantList, error) {
-	re := new(TenantList)
-	url := "http://" + path.Join(dexHost, dexUrlBase, dexApiVersion, tenantListPath)
-	e := doGet(c, url, tenantListCode, re)
+// ListUsers lists a page of users, opts may be nil for all in one page
+func ListUsers(c *http.Client, dexHost string, opts *UserListOptions) (re *UserList, modified bool, e error) {
+	re = new(UserList)
+	url, header := dexListURL(dexHost, usersListPath, opts)
+	modified, e = doGetWithHeader(c, CacheNameCauth, url, header, usersListCode, re)
 	if e != nil {
-		return nil, e
+		return nil, false, e
 	}
-	return re, nil
+	return re, modified, nil
 }
 
-func ListTeams(c *http.Client, dexHost string) (*TeamList, error) {
-	re := new(TeamList)
-	url := "http://" + path.Join(dexHost, dexUrlBase, dexApiVersion, teamsListPath)
-	e := doGet(c, url, teamsListCode, re)
+func ListTenant(c *http.Client, dexHost string, opts *TenantListOptions) (re *TenantList, modified bool, e error) {
+	re = new(TenantList)
+	url, header := dexListURL(dexHost, tenantListPath, opts)
+	modified, e = doGetWithHeader(c, CacheNameCauth, url, header, tenantListCode, re)
 	if e != nil {
-		return nil, e
+		return nil, false, e
 	}
-	return re, nil
+	return re, modified, nil
 }
 
-func ListRoles(c *http.Client, dexHost string) (*RoleList, error) {
-	re := new(RoleList)
-	url := "http://" + path.Join(dexHost, dexUrlBase, dexApiVersion, rolesListPath)
-	e := doGet(c, url, rolesListCode, re)
+func ListTeams(c *http.Client, dexHost string, opts *TeamListOptions) (re *TeamList, modified bool, e error) {
+	re = new(TeamList)
+	url, header := dexListURL(dexHost, teamsListPath, opts)
+	modified, e = doGetWithHeader(c, CacheNameCauth, url, header, teamsListCode, re)
 	if e != nil {
-		return nil, e
+		return nil, false, e
 	}
-	return re, nil
+	return re, modified, nil
 }
 
-func GetUsersMap(c *http.Client, dexHost string) (map[string]*User, error) {
-	usersList, e := ListUsers(c, dexHost)
+func ListRoles(c *http.Client, dexHost string, opts *RoleListOptions) (re *RoleList, modified bool, e error) {
+	re = new(RoleList)
+	url, header := dexListURL(dexHost, rolesListPath, opts)
+	modified, e = doGetWithHeader(c, CacheNameCauth, url, header, rolesListCode, re)
 	if e != nil {
+		return nil, false, e
+	}
+	return re, modified, nil
+}
+
+// ListAllUsers lists the users of opts page by page, the paginator of opts
+// is ignored, and the tenant of opts chooses the X-Tenant header. modified
+// is false if no page is changed since the last list.
+func ListAllUsers(c *http.Client, dexHost string, opts UserListOptions, pageSize int) (re []User, modified bool, e error) {
+	e = listAllPages(pageSize, func(p Paginator) (int, int, error) {
+		opts.Paginator = p
+		list, pageModified, e := ListUsers(c, dexHost, &opts)
+		if e != nil {
+			return 0, 0, e
+		}
+		modified = modified || pageModified
+		re = append(re, list.Items...)
+		return list.Total, len(list.Items), nil
+	})
+	return re, modified, e
+}
+
+func ListAllTenants(c *http.Client, dexHost string, opts TenantListOptions, pageSize int) (re []Tenant, modified bool, e error) {
+	e = listAllPages(pageSize, func(p Paginator) (int, int, error) {
+		opts.Paginator = p
+		list, pageModified, e := ListTenant(c, dexHost, &opts)
+		if e != nil {
+			return 0, 0, e
+		}
+		modified = modified || pageModified
+		re = append(re, list.Items...)
+		return list.Total, len(list.Items), nil
+	})
+	return re, modified, e
+}
+
+func ListAllTeams(c *http.Client, dexHost string, opts TeamListOptions, pageSize int) (re []Team, modified bool, e error) {
+	e = listAllPages(pageSize, func(p Paginator) (int, int, error) {
+		opts.Paginator = p
+		list, pageModified, e := ListTeams(c, dexHost, &opts)
+		if e != nil {
+			return 0, 0, e
+		}
+		modified = modified || pageModified
+		re = append(re, list.Items...)
+		return list.Total, len(list.Items), nil
+	})
+	return re, modified, e
+}
+
+func ListAllRoles(c *http.Client, dexHost string, opts RoleListOptions, pageSize int) (re []Role, modified bool, e error) {
+	e = listAllPages(pageSize, func(p Paginator) (int, int, error) {
+		opts.Paginator = p
+		list, pageModified, e := ListRoles(c, dexHost, &opts)
+		if e != nil {
+			return 0, 0, e
+		}
+		modified = modified || pageModified
+		re = append(re, list.Items...)
+		return list.Total, len(list.Items), nil
+	})
+	return re, modified, e
+}
+
+// GetUsersMap returns nil map if no user is changed since the last call,
+// so are the other maps
+func GetUsersMap(c *http.Client, dexHost string, pageSize int) (map[string]*User, error) {
+	users, modified, e := ListAllUsers(c, dexHost, UserListOptions{}, pageSize)
+	if e != nil || !modified {
 		return nil, e
 	}
-	m := make(map[string]*User, len(usersList.Items))
-	for i := range usersList.Items {
-		user := &usersList.Items[i]
+	m := make(map[string]*User, len(users))
+	for i := range users {
+		user := &users[i]
 		m[user.Username] = user
 	}
 	return m, nil
 }
-func GetTenantMap(c *http.Client, dexHost string) (map[string]*Tenant, error) {
-	tenantList, e := ListTenant(c, dexHost)
-	if e != nil {
+func GetTenantMap(c *http.Client, dexHost string, pageSize int) (map[string]*Tenant, error) {
+	tenants, modified, e := ListAllTenants(c, dexHost, TenantListOptions{}, pageSize)
+	if e != nil || !modified {
 		return nil, e
 	}
-	m := make(map[string]*Tenant, len(tenantList.Items))
-	for i := range tenantList.Items {
-		tenant := &tenantList.Items[i]
+	m := make(map[string]*Tenant, len(tenants))
+	for i := range tenants {
+		tenant := &tenants[i]
 		m[tenant.ID] = tenant
 	}
 	return m, nil
 }
-func GetTeamsMap(c *http.Client, dexHost string) (map[string]*Team, error) {
-	teamsList, e := ListTeams(c, dexHost)
-	if e != nil {
+func GetTeamsMap(c *http.Client, dexHost string, pageSize int) (map[string]*Team, error) {
+	teams, modified, e := ListAllTeams(c, dexHost, TeamListOptions{}, pageSize)
+	if e != nil || !modified {
 		return nil, e
 	}
-	m := make(map[string]*Team, len(teamsList.Items))
-	for i := range teamsList.Items {
-		team := &teamsList.Items[i]
+	m := make(map[string]*Team, len(teams))
+	for i := range teams {
+		team := &teams[i]
 		m[team.ID] = team
 	}
 	return m, nil
 }
-func GetRolesMap(c *http.Client, dexHost string) (map[string]*Role, error) {
-	rolesList, e := ListRoles(c, dexHost)
-	if e != nil {
+func GetRolesMap(c *http.Client, dexHost string, pageSize int) (map[string]*Role, error) {
+	roles, modified, e := ListAllRoles(c, dexHost, RoleListOptions{}, pageSize)
+	if e != nil || !modified {
 		return nil, e
 	}
-	m := make(map[string]*Role, len(rolesList.Items))
-	for i := range rolesList.Items {
-		role := &rolesList.Items[i]
+	m := make(map[string]*Role, len(roles))
+	for i := range roles {
+		role := &roles[i]
 		m[role.ID] = role
 	}
 	return m, nil
@@ -1,8 +1,8 @@
 package api
 
 import (
+	"context"
 	"net/http"
-	"path"
 )
 
 const (
@@ -16,22 +16,26 @@ const (
 	pipelinesListCode  = 200
 )
 
-func ListWorkspaces(c *http.Client, devopHost string) (*WorkspaceList, error) {
-	re := new(WorkspaceList)
-	url := "http://" + path.Join(devopHost, devopUrlBase, devopApiVersion, workspacesListPath)
-	e := doGet(c, url, workspacesListCode, re)
+// ListWorkspaces lists the workspaces of tenant, empty tenant means without
+// X-Tenant header
+func ListWorkspaces(c *http.Client, devopHost, tenant string) (re *WorkspaceList, modified bool, e error) {
+	re = new(WorkspaceList)
+	url := upstreamURL(devopHost, devopUrlBase, devopApiVersion, workspacesListPath)
+	modified, e = doGetWithHeader(c, CacheNameDevopAdmin, url, tenantHeader(tenant), workspacesListCode, re)
 	if e != nil {
-		return nil, e
+		return nil, false, e
 	}
-	return re, nil
+	return re, modified, nil
 }
 
-func ListPipelines(c *http.Client, devopHost, workspace string) (*PipelineList, error) {
-	re := new(PipelineList)
-	url := "http://" + path.Join(devopHost, devopUrlBase, devopApiVersion, workspacesListPath, workspace, pipelinesListPath)
-	e := doGet(c, url, pipelinesListCode, re)
+func ListPipelines(c *http.Client, devopHost, tenant, workspace string) (re *PipelineList, modified bool, e error) {
+	re = new(PipelineList)
+	url := upstreamURL(devopHost, devopUrlBase, devopApiVersion, workspacesListPath, workspace, pipelinesListPath)
+	// a workspace failing is stale alone, so is not counted by the breaker
+	modified, e = doGetWithContext(notCountedByBreaker(context.Background()), c, CacheNameDevopAdmin, url,
+		tenantHeader(tenant), pipelinesListCode, re)
 	if e != nil {
-		return nil, e
+		return nil, false, e
 	}
-	return re, nil
+	return re, modified, nil
 }
@@ -1,38 +1,348 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/caicloud/dashboard-admin/pkg/constants"
+	"github.com/caicloud/dashboard-admin/pkg/errors"
 )
 
 func NewHttpClient(timeout time.Duration) (*http.Client, error) {
-	if timeout < 0 {
-		return nil, fmt.Errorf("illegal timeout: %v", timeout)
+	return NewUpstreamClient(ClientConfig{Timeout: timeout})
+}
+
+// ClientConfig of the client of an upstream, files are optional
+type ClientConfig struct {
+	Timeout time.Duration
+	// pem CA bundle to verify the upstream instead of the system roots
+	CAFile string
+	// pem client certificate and key
+	CertFile string
+	KeyFile  string
+	// bearer token, or "username:password" of basic auth, re-read on change
+	TokenFile     string
+	BasicAuthFile string
+}
+
+func NewUpstreamClient(cfg ClientConfig) (*http.Client, error) {
+	if cfg.Timeout < 0 {
+		return nil, fmt.Errorf("illegal timeout: %v", cfg.Timeout)
+	}
+	if len(cfg.TokenFile) > 0 && len(cfg.BasicAuthFile) > 0 {
+		return nil, fmt.Errorf("both token and basic auth are set")
+	}
+	if (len(cfg.CertFile) > 0) != (len(cfg.KeyFile) > 0) {
+		return nil, fmt.Errorf("client certificate and key must be set together")
+	}
+	tlsConfig := &tls.Config{}
+	if len(cfg.CAFile) > 0 {
+		b, e := ioutil.ReadFile(cfg.CAFile)
+		if e != nil {
+			return nil, fmt.Errorf("read ca file failed, %v", e)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificate in ca file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(cfg.CertFile) > 0 {
+		cert, e := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if e != nil {
+			return nil, fmt.Errorf("load client certificate failed, %v", e)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	var rt http.RoundTripper = &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	switch {
+	case len(cfg.TokenFile) > 0:
+		rt = &authRoundTripper{rt: rt, secret: &secretFile{path: cfg.TokenFile}}
+	case len(cfg.BasicAuthFile) > 0:
+		rt = &authRoundTripper{rt: rt, secret: &secretFile{path: cfg.BasicAuthFile}, basic: true}
 	}
 	return &http.Client{
-		Timeout: timeout,
+		Transport: rt,
+		Timeout:   cfg.Timeout,
 	}, nil
 }
 
-func doGet(c *http.Client, url string, expectedCode int, result interface{}) error {
-	resp, e := c.Get(url)
+// authRoundTripper sets the authorization header of every request
+type authRoundTripper struct {
+	rt     http.RoundTripper
+	secret *secretFile
+	basic  bool
+}
+
+func (a *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	secret, e := a.secret.Get()
 	if e != nil {
-		return e
+		return nil, e
+	}
+	// must not modify the request
+	req2 := new(http.Request)
+	*req2 = *req
+	req2.Header = make(http.Header, len(req.Header)+1)
+	for k, vs := range req.Header {
+		req2.Header[k] = vs
+	}
+	if a.basic {
+		parts := strings.SplitN(secret, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("basic auth file %s is not username:password", a.secret.path)
+		}
+		req2.SetBasicAuth(parts[0], parts[1])
+	} else {
+		req2.Header.Set("Authorization", "Bearer "+secret)
+	}
+	return a.rt.RoundTrip(req2)
+}
+
+// secretFile is the trimmed content of a file, re-read when the file changes
+type secretFile struct {
+	path string
+
+	lock    sync.Mutex
+	modTime time.Time
+	value   string
+}
+
+func (s *secretFile) Get() (string, error) {
+	fi, e := os.Stat(s.path)
+	if e != nil {
+		return "", e
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !fi.ModTime().Equal(s.modTime) || len(s.value) == 0 {
+		b, e := ioutil.ReadFile(s.path)
+		if e != nil {
+			return "", e
+		}
+		s.value = strings.TrimSpace(string(b))
+		s.modTime = fi.ModTime()
+	}
+	if len(s.value) == 0 {
+		return "", fmt.Errorf("empty secret file %s", s.path)
+	}
+	return s.value, nil
+}
+
+// upstreamURL joins elems to the path of host, host is a url with scheme,
+// or only host:port which means http
+func upstreamURL(host string, elems ...string) string {
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	u, e := url.Parse(host)
+	if e != nil {
+		// checked by config, keep the old behavior
+		return host + "/" + path.Join(elems...)
+	}
+	u.Path = path.Join(append([]string{"/", u.Path}, elems...)...)
+	return u.String()
+}
+
+// tenantHeader chooses the tenant of requests, empty means no tenant
+func tenantHeader(tenant string) http.Header {
+	if len(tenant) == 0 {
+		return nil
+	}
+	return http.Header{constants.ParameterXTenant: []string{tenant}}
+}
+
+// doGet gets url of service into result, modified is false if the body is
+// the same as the last one, result is then copied from the last one
+func doGet(c *http.Client, service, url string, expectedCode int, result interface{}) (modified bool, e error) {
+	return doGetWithHeader(c, service, url, nil, expectedCode, result)
+}
+
+// doGetWithHeader gets url of service into result, failures of the upstream
+// are returned as FormatError. The validators of the last saved response are
+// sent, and a 304 or the same body skips decoding. The validators of this
+// response are staged until the refresh succeeds.
+func doGetWithHeader(c *http.Client, service, url string, header http.Header, expectedCode int,
+	result interface{}) (modified bool, e error) {
+	return doGetWithContext(context.Background(), c, service, url, header, expectedCode, result)
+}
+
+// doGetWithContext is doGetWithHeader with the context of the request
+func doGetWithContext(ctx context.Context, c *http.Client, service, url string, header http.Header, expectedCode int,
+	result interface{}) (modified bool, e error) {
+	req, e := http.NewRequest(http.MethodGet, url, nil)
+	if e != nil {
+		return false, e
+	}
+	req = req.WithContext(ctx)
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	vc, key := validatorsOf(c), validatorKey(url, header)
+	last := vc.get(key)
+	if last != nil {
+		if len(last.etag) > 0 {
+			req.Header.Set("If-None-Match", last.etag)
+		}
+		if len(last.lastModified) > 0 {
+			req.Header.Set("If-Modified-Since", last.lastModified)
+		}
+	}
+	resp, e := c.Do(req)
+	if e != nil {
+		return false, upstreamError(service, nil, nil, e)
 	}
 	defer resp.Body.Close()
 	b, e := ioutil.ReadAll(resp.Body)
 	if e != nil {
-		return e
+		return false, upstreamError(service, nil, nil, e)
+	}
+	if resp.StatusCode == http.StatusNotModified && last != nil && last.copyTo(result) {
+		vc.stage(key, last.withValidators(resp))
+		return false, nil
 	}
 	if resp.StatusCode != expectedCode {
-		return fmt.Errorf("unexpected code, %v != %v, %s", resp.StatusCode, expectedCode, string(b))
+		return false, upstreamError(service, resp, b,
+			fmt.Errorf("unexpected code, %v != %v, %s", resp.StatusCode, expectedCode, string(b)))
+	}
+	sum := sha256.Sum256(b)
+	if last != nil && last.sum == sum && last.copyTo(result) {
+		vc.stage(key, last.withValidators(resp))
+		return false, nil
 	}
 	e = json.Unmarshal(b, result)
 	if e != nil {
-		return fmt.Errorf("unmarshal failed, [%v]'%s', %v", resp.StatusCode, string(b), e)
+		return false, upstreamError(service, resp, b,
+			fmt.Errorf("unmarshal failed, [%v]'%s', %v", resp.StatusCode, string(b), e))
+	}
+	vc.stage(key, newValidatorEntry(resp, sum, result))
+	return true, nil
+}
+
+// upstreamError converts a failed request to service into a FormatError with
+// the reason in the error body, resp is nil if the upstream is not reachable
+func upstreamError(service string, resp *http.Response, body []byte, e error) *errors.FormatError {
+	data := &errors.UpstreamErrorData{Service: service}
+	if resp == nil {
+		return errors.NewError().SetErrorUpstreamUnavailable(data, e)
+	}
+	data.HttpCode = resp.StatusCode
+	if data.HttpCode >= http.StatusBadRequest {
+		parseUpstreamErrorBody(data, resp, body)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return errors.NewError().SetErrorUpstreamUnavailable(data, e)
+	}
+	return errors.NewError().SetErrorUpstreamBadResponse(data, e)
+}
+
+// parseUpstreamErrorBody fills data by the error body of the upstream, which
+// is a DaErrorResponse for devops admin, or an ApiError for the others
+func parseUpstreamErrorBody(data *errors.UpstreamErrorData, resp *http.Response, body []byte) {
+	if data.Service == CacheNameDevopAdmin {
+		der := new(DaErrorResponse)
+		if json.Unmarshal(body, der) == nil {
+			data.Reason, data.Message, data.Details = der.Reason, der.Message, der.Details
+		}
+		return
+	}
+	// body is read already
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if fe, e := errors.ParseResponseError(resp); e == nil {
+		data.Reason, data.Message = string(fe.Reason), fe.ApiError.Message
+	}
+}
+
+// encodeListOptions puts the non-zero fields of opts into query or header by
+// their url tags, named by their json tags. Embedded structs are inlined.
+func encodeListOptions(opts interface{}) (url.Values, http.Header) {
+	query, header := url.Values{}, http.Header{}
+	if opts != nil {
+		encodeOptionFields(reflect.Indirect(reflect.ValueOf(opts)), query, header)
+	}
+	return query, header
+}
+
+func encodeOptionFields(v reflect.Value, query url.Values, header http.Header) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if f.Anonymous && fv.Kind() == reflect.Struct {
+			encodeOptionFields(fv, query, header)
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if len(name) == 0 || name == "-" {
+			continue
+		}
+		var values []string
+		switch fv.Kind() {
+		case reflect.String:
+			if s := fv.String(); len(s) > 0 {
+				values = append(values, s)
+			}
+		case reflect.Int:
+			if n := fv.Int(); n != 0 {
+				values = append(values, strconv.FormatInt(n, 10))
+			}
+		case reflect.Bool:
+			if fv.Bool() {
+				values = append(values, "true")
+			}
+		case reflect.Slice:
+			for j := 0; j < fv.Len(); j++ {
+				if s := fmt.Sprint(fv.Index(j).Interface()); len(s) > 0 {
+					values = append(values, s)
+				}
+			}
+		}
+		for _, s := range values {
+			switch f.Tag.Get("url") {
+			case "query":
+				query.Add(name, s)
+			case "header":
+				header.Add(name, s)
+			}
+		}
+	}
+}
+
+// listAllPages calls list from start 0 with limit pageSize until total is
+// reached or a page is empty, list returns the total and item number of a page
+func listAllPages(pageSize int, list func(p Paginator) (total, n int, e error)) error {
+	for start := 0; ; {
+		total, n, e := list(Paginator{Start: start, Limit: pageSize})
+		if e != nil {
+			return upstreamErrorf([]error{e}, "list from %d failed, %v", start, e)
+		}
+		start += n
+		if n == 0 || start >= total {
+			return nil
+		}
 	}
-	return nil
 }
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"github.com/caicloud/dashboard-admin/pkg/cache/notify"
+)
+
+// refreshTopics are the topics changed by the data of the upstream caches,
+// the caches not listed change no topic pushed to watchers
+var refreshTopics = map[string][]notify.Topic{
+	CacheNameDevopAdmin: {notify.TopicCI},
+}
+
+// SetNotifier must be called before Run
+func (c *Cache) SetNotifier(n notify.Notifier) {
+	c.notifier = n
+}
+
+// refreshed tells the notifier the topics of r changed
+func (c *Cache) refreshed(r Refresher) {
+	if c.notifier == nil {
+		return
+	}
+	for _, topic := range refreshTopics[r.Name()] {
+		c.notifier.Notify(notify.Change{Topic: topic})
+	}
+}
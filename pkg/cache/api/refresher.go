@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/caicloud/nirvana/log"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+const (
+	// max factor of jitter added to the refresh interval and the backoff
+	refreshJitterFactor = 0.1
+)
+
+type Refresher interface {
+	Name() string
+	// Slug is the url safe name
+	Slug() string
+	// Refresh refreshes the data from host, changed tells whether the data
+	// is replaced, which may be true even if it fails partly
+	Refresh(client *http.Client, host string) (changed bool, e error)
+}
+
+type RefreshConfig struct {
+	Interval time.Duration
+	// wait before the first retry after a failure, capped by the interval,
+	// 0 means the interval
+	InitialBackoff time.Duration
+	// max wait between retries after failures
+	MaxBackoff time.Duration
+	// data not refreshed in it is stale, 0 means only data never refreshed is stale
+	MaxStaleness time.Duration
+}
+
+// RefreshState is the state of the refresher of an upstream
+type RefreshState struct {
+	Name             string    `json:"name"`
+	Slug             string    `json:"slug"`
+	Host             string    `json:"host"`
+	LastSuccess      time.Time `json:"lastSuccess"`
+	LastError        time.Time `json:"lastError"`
+	LastErrorMessage string    `json:"lastErrorMessage,omitempty"`
+	// reason, http code and service of the last error if it is from the upstream
+	LastFailure         *errors.FormatError `json:"lastFailure,omitempty"`
+	ConsecutiveFailures int                 `json:"consecutiveFailures"`
+	NextRefresh         time.Time           `json:"nextRefresh"`
+	Stale               bool                `json:"stale"`
+	// circuit breaker of the upstream, open means its data is temporarily unavailable
+	Breaker BreakerState `json:"breaker"`
+}
+
+type refreshRunner struct {
+	client  *http.Client
+	breaker *Breaker
+	host    string
+	r       Refresher
+	cfg     RefreshConfig
+	// of the responses of client, staged by a refresh
+	validators *validatorCache
+	// called after every refresh which changed the data if not nil
+	refreshed func(r Refresher)
+
+	lock     sync.RWMutex
+	state    RefreshState
+	inflight *refreshCall
+	// delay after an on-demand refresh, which replaces the timer of Run
+	rearm chan time.Duration
+}
+
+// refreshCall is a refresh shared by all the callers during it
+type refreshCall struct {
+	done  chan struct{}
+	e     error
+	start time.Time
+	cost  time.Duration
+	delay time.Duration
+}
+
+// RefreshResult is the result of an on-demand refresh
+type RefreshResult struct {
+	Name  string    `json:"name"`
+	Slug  string    `json:"slug"`
+	Start time.Time `json:"start"`
+	// milliseconds the refresh took
+	Duration int64 `json:"duration"`
+	// joined a refresh already in flight
+	Coalesced bool                `json:"coalesced"`
+	Error     *errors.FormatError `json:"error,omitempty"`
+}
+
+func newRefreshRunner(client *http.Client, breaker *Breaker, host string, r Refresher, cfg RefreshConfig,
+	refreshed func(r Refresher)) *refreshRunner {
+	vc := newValidatorCache()
+	return &refreshRunner{
+		client:     withValidators(client, vc),
+		validators: vc,
+		breaker:    breaker,
+		host:       host,
+		r:          r,
+		cfg:        cfg,
+		refreshed:  refreshed,
+		state:      RefreshState{Name: r.Name(), Slug: r.Slug(), Host: host},
+		rearm:      make(chan time.Duration, 1),
+	}
+}
+
+// Run refreshes at once, then every interval with jitter, or with
+// exponential backoff after failures
+func (rr *refreshRunner) Run(stopCh chan struct{}) {
+	name := rr.r.Name()
+	log.Infof("%s cache start in refresh time: %v", name, rr.cfg.Interval)
+	call, _ := rr.refresh()
+	t := time.NewTimer(call.delay)
+	for {
+		select {
+		case <-stopCh:
+			t.Stop()
+			log.Warningf("%s cache stopped", name)
+			return
+		case delay := <-rr.rearm:
+			if !t.Stop() {
+				select {
+				case <-t.C:
+				default:
+				}
+			}
+			t.Reset(delay)
+		case <-t.C:
+			call, _ := rr.refresh()
+			// drop the rearm of this call if it is an on-demand one
+			select {
+			case <-rr.rearm:
+			default:
+			}
+			t.Reset(call.delay)
+		}
+	}
+}
+
+// Trigger refreshes at once, or joins the refresh in flight, and waits for
+// its result until ctx is done
+func (rr *refreshRunner) Trigger(ctx context.Context) (*RefreshResult, error) {
+	type triggered struct {
+		call      *refreshCall
+		coalesced bool
+	}
+	ch := make(chan triggered, 1)
+	go func() {
+		call, coalesced := rr.refresh()
+		if !coalesced {
+			rr.setRearm(call.delay)
+		}
+		ch <- triggered{call: call, coalesced: coalesced}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case t := <-ch:
+		re := &RefreshResult{
+			Name:      rr.r.Name(),
+			Slug:      rr.r.Slug(),
+			Start:     t.call.start,
+			Duration:  int64(t.call.cost / time.Millisecond),
+			Coalesced: t.coalesced,
+		}
+		if t.call.e != nil {
+			fe, ok := errors.GetFormatError(t.call.e)
+			if !ok {
+				fe = errors.NewError().SetErrorInternalServerError(t.call.e)
+			}
+			re.Error = fe
+		}
+		return re, nil
+	}
+}
+
+// setRearm replaces the delay of Run with delay, the older one not taken by
+// Run is dropped
+func (rr *refreshRunner) setRearm(delay time.Duration) {
+	for {
+		select {
+		case rr.rearm <- delay:
+			return
+		default:
+		}
+		select {
+		case <-rr.rearm:
+		default:
+		}
+	}
+}
+
+// refresh refreshes once and returns the call with the delay of the next
+// one, or waits for the call in flight if any
+func (rr *refreshRunner) refresh() (call *refreshCall, coalesced bool) {
+	rr.lock.Lock()
+	if rr.inflight != nil {
+		call = rr.inflight
+		rr.lock.Unlock()
+		<-call.done
+		return call, true
+	}
+	call = &refreshCall{done: make(chan struct{}), start: time.Now()}
+	rr.inflight = call
+	rr.lock.Unlock()
+
+	name := rr.r.Name()
+	rr.validators.discard()
+	changed, e := rr.r.Refresh(rr.client, rr.host)
+	cost := time.Now().Sub(call.start)
+	// the data of a failed refresh may be partly dropped, so are the validators
+	if e == nil {
+		rr.validators.commit()
+	} else {
+		rr.validators.discard()
+	}
+
+	rr.lock.Lock()
+	if e == nil {
+		rr.state.LastSuccess = time.Now()
+		rr.state.ConsecutiveFailures = 0
+	} else {
+		rr.state.LastError = time.Now()
+		rr.state.LastErrorMessage = e.Error()
+		rr.state.LastFailure, _ = errors.GetFormatError(e)
+		rr.state.ConsecutiveFailures++
+	}
+	delay := refreshDelay(rr.cfg, rr.state.ConsecutiveFailures)
+	rr.state.NextRefresh = time.Now().Add(delay)
+	failures := rr.state.ConsecutiveFailures
+	call.e, call.cost, call.delay = e, cost, delay
+	rr.inflight = nil
+	rr.lock.Unlock()
+	close(call.done)
+
+	if e != nil {
+		log.Errorf("%s cache refresh failed %d times in %v, retry in %v, %v", name, failures, cost, delay, e)
+	} else {
+		log.Infof("%s cache refresh done in %v, changed %v", name, cost, changed)
+	}
+	if changed && rr.refreshed != nil {
+		rr.refreshed(rr.r)
+	}
+	return call, false
+}
+
+// refreshDelay is the interval with jitter if no failure, or the backoff of
+// failures with jitter, which starts from min(interval, initial backoff) and
+// is doubled on every following failure up to max backoff
+func refreshDelay(cfg RefreshConfig, failures int) time.Duration {
+	if failures == 0 {
+		return wait.Jitter(cfg.Interval, refreshJitterFactor)
+	}
+	backoff := cfg.InitialBackoff
+	if backoff <= 0 || backoff > cfg.Interval {
+		backoff = cfg.Interval
+	}
+	for i := 1; i < failures && backoff < cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > cfg.MaxBackoff {
+		backoff = cfg.MaxBackoff
+	}
+	return wait.Jitter(backoff, refreshJitterFactor)
+}
+
+func (rr *refreshRunner) State() RefreshState {
+	rr.lock.RLock()
+	defer rr.lock.RUnlock()
+	re := rr.state
+	re.Stale = rr.isStale(time.Now())
+	if rr.breaker != nil {
+		re.Breaker = rr.breaker.State()
+	}
+	return re
+}
+
+// isStale must be called with lock held
+func (rr *refreshRunner) isStale(now time.Time) bool {
+	if rr.state.LastSuccess.IsZero() {
+		return true
+	}
+	return rr.cfg.MaxStaleness > 0 && now.Sub(rr.state.LastSuccess) > rr.cfg.MaxStaleness
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefreshDelay(t *testing.T) {
+	cfg := RefreshConfig{Interval: 30 * time.Second, InitialBackoff: 5 * time.Second, MaxBackoff: 60 * time.Second}
+	for _, c := range []struct {
+		name     string
+		cfg      RefreshConfig
+		failures int
+		want     time.Duration
+	}{
+		{"healthy", cfg, 0, 30 * time.Second},
+		{"first failure", cfg, 1, 5 * time.Second},
+		{"doubled", cfg, 3, 20 * time.Second},
+		{"beyond the interval", cfg, 4, 40 * time.Second},
+		{"capped", cfg, 10, 60 * time.Second},
+		{"no initial backoff", RefreshConfig{Interval: 30 * time.Second, MaxBackoff: 60 * time.Second}, 1, 30 * time.Second},
+		{"initial backoff over the interval", RefreshConfig{Interval: 2 * time.Second, InitialBackoff: 5 * time.Second, MaxBackoff: 60 * time.Second}, 2, 4 * time.Second},
+		{"max backoff under the interval", RefreshConfig{Interval: 30 * time.Second, InitialBackoff: 30 * time.Second, MaxBackoff: 10 * time.Second}, 1, 10 * time.Second},
+	} {
+		got := refreshDelay(c.cfg, c.failures)
+		max := c.want + time.Duration(float64(c.want)*refreshJitterFactor)
+		if got < c.want || got > max {
+			t.Errorf("%s: expect delay in [%v, %v], got %v", c.name, c.want, max, got)
+		}
+	}
+}
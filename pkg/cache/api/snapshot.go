@@ -0,0 +1,180 @@
+package api
+
+import (
+	"time"
+
+	"github.com/caicloud/dashboard-admin/pkg/cache/snapshot"
+)
+
+// Snapshots of the caches, restored data is served as stale until the
+// first successful refresh.
+
+func (c *Cache) Snapshotters() []snapshot.Snapshotter {
+	return []snapshot.Snapshotter{c.CauthCache, c.DevopCache, c.CargoCache}
+}
+
+type cauthSnapshot struct {
+	Users   map[string]*User   `json:"users"`
+	Teams   map[string]*Team   `json:"teams"`
+	Tenants map[string]*Tenant `json:"tenants"`
+	Roles   map[string]*Role   `json:"roles"`
+}
+
+func (c *CauthCache) SnapshotName() string {
+	return c.Slug()
+}
+
+func (c *CauthCache) Snapshot() (interface{}, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.synced {
+		return nil, false
+	}
+	users := make(map[string]*User, len(c.users))
+	for k, u := range c.users {
+		if u != nil && len(u.Password) > 0 {
+			nu := *u
+			nu.Password = ""
+			u = &nu
+		}
+		users[k] = u
+	}
+	// copied as the maps are marshaled after unlock
+	re := &cauthSnapshot{
+		Users:   users,
+		Teams:   make(map[string]*Team, len(c.teams)),
+		Tenants: make(map[string]*Tenant, len(c.tenants)),
+		Roles:   make(map[string]*Role, len(c.roles)),
+	}
+	for k, v := range c.teams {
+		re.Teams[k] = v
+	}
+	for k, v := range c.tenants {
+		re.Tenants[k] = v
+	}
+	for k, v := range c.roles {
+		re.Roles[k] = v
+	}
+	return re, true
+}
+
+func (c *CauthCache) Restore(savedAt time.Time, decode func(v interface{}) error) error {
+	s := new(cauthSnapshot)
+	if e := decode(s); e != nil {
+		return e
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.synced {
+		c.users, c.teams, c.tenants, c.roles = s.Users, s.Teams, s.Tenants, s.Roles
+	}
+	return nil
+}
+
+// IsStale tells whether the data is not refreshed yet, maybe restored from snapshot
+func (c *CauthCache) IsStale() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return !c.synced
+}
+
+func (c *DaCache) SnapshotName() string {
+	return c.Slug()
+}
+
+func (c *DaCache) Snapshot() (interface{}, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.synced {
+		return nil, false
+	}
+	wsMap := make(map[string]*WorkspaceDetail, len(c.wsMap))
+	for k, v := range c.wsMap {
+		wsMap[k] = v
+	}
+	return wsMap, true
+}
+
+func (c *DaCache) Restore(savedAt time.Time, decode func(v interface{}) error) error {
+	var wsMap map[string]*WorkspaceDetail
+	if e := decode(&wsMap); e != nil {
+		return e
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.synced {
+		c.wsMap = wsMap
+	}
+	return nil
+}
+
+func (c *DaCache) IsStale() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return !c.synced
+}
+
+func (c *CargoCache) SnapshotName() string {
+	return c.Slug()
+}
+
+type cargoSnapshot struct {
+	Registries map[string]*Registry
+	Projects   map[string]map[string]*ProjectDetail
+}
+
+func (c *CargoCache) Snapshot() (interface{}, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.synced {
+		return nil, false
+	}
+	// credentials of the registries are never saved
+	registries := make(map[string]*Registry, len(c.registries))
+	for k, r := range c.registries {
+		if r != nil && r.Spec != nil {
+			nr, spec := *r, *r.Spec
+			spec.Username, spec.Password = "", ""
+			nr.Spec = &spec
+			r = &nr
+		}
+		registries[k] = r
+	}
+	projects := make(map[string]map[string]*ProjectDetail, len(c.projects))
+	for k, m := range c.projects {
+		pm := make(map[string]*ProjectDetail, len(m))
+		for name, pd := range m {
+			pm[name] = pd
+		}
+		projects[k] = pm
+	}
+	return &cargoSnapshot{Registries: registries, Projects: projects}, true
+}
+
+func (c *CargoCache) Restore(savedAt time.Time, decode func(v interface{}) error) error {
+	s := new(cargoSnapshot)
+	if e := decode(s); e != nil {
+		return e
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.synced {
+		c.registries, c.projects = s.Registries, s.Projects
+		c.restored = true
+	}
+	return nil
+}
+
+func (c *CargoCache) IsStale() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return !c.synced
+}
+
+// IsLoaded tells whether the cache has been refreshed or restored, an empty
+// cache before that knows nothing about the registries
+func (c *CargoCache) IsLoaded() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.synced || c.restored
+}
@@ -72,3 +72,66 @@ type RegistryList struct {
 	Metadata CaListMeta `json:"metadata"`
 	Items    []Registry `json:"items"`
 }
+
+// =================================================================================================
+
+type Project struct {
+	Metadata *ProjectMetadata `json:"metadata"`
+	Spec     *ProjectSpec     `json:"spec"`
+	Status   *ProjectStatus   `json:"status"`
+}
+
+type ProjectMetadata struct {
+	Name           string    `json:"name"`
+	CreationTime   time.Time `json:"creationTime"`
+	LastUpdateTime time.Time `json:"lastUpdateTime"`
+}
+
+type ProjectSpec struct {
+	IsPublic    bool   `json:"isPublic"`
+	IsProtected bool   `json:"isProtected"`
+	Registry    string `json:"registry"`
+	Description string `json:"description"`
+}
+
+type ProjectStatus struct {
+	Synced           bool      `json:"synced"`
+	RepositoryCount  int64     `json:"repositoryCount"`
+	LastActivityTime time.Time `json:"lastActivityTime"`
+}
+
+type ProjectList struct {
+	Metadata CaListMeta `json:"metadata"`
+	Items    []Project  `json:"items"`
+}
+
+// =================================================================================================
+
+type Repository struct {
+	Metadata *RepositoryMetadata `json:"metadata"`
+	Spec     *RepositorySpec     `json:"spec"`
+	Status   *RepositoryStatus   `json:"status"`
+}
+
+type RepositoryMetadata struct {
+	Name           string    `json:"name"`
+	CreationTime   time.Time `json:"creationTime"`
+	LastUpdateTime time.Time `json:"lastUpdateTime"`
+}
+
+type RepositorySpec struct {
+	Project  string `json:"project"`
+	Registry string `json:"registry"`
+}
+
+type RepositoryStatus struct {
+	TagCount  int64 `json:"tagCount"`
+	PullCount int64 `json:"pullCount"`
+	// bytes of all the tags
+	Size int64 `json:"size"`
+}
+
+type RepositoryList struct {
+	Metadata CaListMeta   `json:"metadata"`
+	Items    []Repository `json:"items"`
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	// validators of urls not fetched in it are dropped
+	validatorTTL = time.Hour
+)
+
+// validatingTransport carries the validators of the responses of a client
+// of a refresh runner, so they live and die with the runner, and the decoded
+// bodies are only copied into the cache which has seen them
+type validatingTransport struct {
+	rt http.RoundTripper
+	vc *validatorCache
+}
+
+func (t *validatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.rt.RoundTrip(req)
+}
+
+// withValidators returns a copy of c whose responses are validated by vc
+func withValidators(c *http.Client, vc *validatorCache) *http.Client {
+	re := *c
+	rt := c.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	re.Transport = &validatingTransport{rt: rt, vc: vc}
+	return &re
+}
+
+// validatorsOf returns the validators of c, nil if it is not from
+// withValidators, whose requests are never conditional
+func validatorsOf(c *http.Client) *validatorCache {
+	if t, ok := c.Transport.(*validatingTransport); ok {
+		return t.vc
+	}
+	return nil
+}
+
+// validatorEntry is the last response of a url
+type validatorEntry struct {
+	etag         string
+	lastModified string
+	sum          [sha256.Size]byte
+	// copy of the decoded body, shared by the results copied from it, which
+	// are read only as the slices and pointers in them are not copied
+	result   interface{}
+	lastUsed time.Time
+}
+
+func newValidatorEntry(resp *http.Response, sum [sha256.Size]byte, result interface{}) *validatorEntry {
+	v := reflect.ValueOf(result)
+	re := &validatorEntry{sum: sum}
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		cp := reflect.New(v.Elem().Type())
+		cp.Elem().Set(v.Elem())
+		re.result = cp.Interface()
+	}
+	return re.withValidators(resp)
+}
+
+// withValidators returns a copy of ve with the validators of resp, the ones
+// of ve are kept if resp has none
+func (ve *validatorEntry) withValidators(resp *http.Response) *validatorEntry {
+	re := *ve
+	if etag := resp.Header.Get("ETag"); len(etag) > 0 {
+		re.etag = etag
+	}
+	if lastModified := resp.Header.Get("Last-Modified"); len(lastModified) > 0 {
+		re.lastModified = lastModified
+	}
+	return &re
+}
+
+// copyTo sets result to a shallow copy of the last decoded body, false if the
+// types differ
+func (ve *validatorEntry) copyTo(result interface{}) bool {
+	if ve.result == nil {
+		return false
+	}
+	dst, src := reflect.ValueOf(result), reflect.ValueOf(ve.result)
+	if dst.Kind() != reflect.Ptr || dst.IsNil() || dst.Type() != src.Type() {
+		return false
+	}
+	dst.Elem().Set(src.Elem())
+	return true
+}
+
+func validatorKey(url string, header http.Header) string {
+	if len(header) == 0 {
+		return url
+	}
+	var b bytes.Buffer
+	b.WriteString(url)
+	b.WriteString("\n")
+	// sorted by key
+	header.Write(&b)
+	return b.String()
+}
+
+// validatorCache keeps the validators of the responses of a refresher. The
+// validators of a refresh are staged and only saved if the refresh succeeds,
+// so the changes a failed refresh has not kept are fetched again. A nil one
+// keeps nothing.
+type validatorCache struct {
+	lock      sync.Mutex
+	entries   map[string]*validatorEntry
+	staged    map[string]*validatorEntry
+	lastPrune time.Time
+}
+
+func newValidatorCache() *validatorCache {
+	return &validatorCache{
+		entries: make(map[string]*validatorEntry),
+		staged:  make(map[string]*validatorEntry),
+	}
+}
+
+// get returns the saved entry of key, staged ones are not seen
+func (vc *validatorCache) get(key string) *validatorEntry {
+	if vc == nil {
+		return nil
+	}
+	vc.lock.Lock()
+	defer vc.lock.Unlock()
+	return vc.entries[key]
+}
+
+// stage keeps ve until commit or discard
+func (vc *validatorCache) stage(key string, ve *validatorEntry) {
+	if vc == nil {
+		return
+	}
+	vc.lock.Lock()
+	defer vc.lock.Unlock()
+	ve.lastUsed = time.Now()
+	vc.staged[key] = ve
+}
+
+// commit saves the staged entries
+func (vc *validatorCache) commit() {
+	if vc == nil {
+		return
+	}
+	vc.lock.Lock()
+	defer vc.lock.Unlock()
+	for k, ve := range vc.staged {
+		vc.entries[k] = ve
+	}
+	vc.staged = make(map[string]*validatorEntry)
+	now := time.Now()
+	if now.Sub(vc.lastPrune) < validatorTTL {
+		return
+	}
+	vc.lastPrune = now
+	for k, e := range vc.entries {
+		if now.Sub(e.lastUsed) > validatorTTL {
+			delete(vc.entries, k)
+		}
+	}
+}
+
+// discard drops the staged entries
+func (vc *validatorCache) discard() {
+	if vc == nil {
+		return
+	}
+	vc.lock.Lock()
+	defer vc.lock.Unlock()
+	vc.staged = make(map[string]*validatorEntry)
+}
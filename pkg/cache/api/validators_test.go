@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// testETagServer answers a workspace list of the version, 304 if the client
+// has it, and records the conditional requests
+type testETagServer struct {
+	*httptest.Server
+
+	lock        sync.Mutex
+	version     string
+	conditional int
+}
+
+func newTestETagServer() *testETagServer {
+	s := &testETagServer{version: "1"}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		etag := `"` + s.version + `"`
+		if inm := r.Header.Get("If-None-Match"); len(inm) > 0 {
+			s.conditional++
+			if inm == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Header().Set("ETag", etag)
+		json.NewEncoder(w).Encode(&WorkspaceList{Items: []Workspace{{Name: "v" + s.version}}})
+	}))
+	return s
+}
+
+func (s *testETagServer) setVersion(version string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.version = version
+}
+
+func (s *testETagServer) conditionals() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.conditional
+}
+
+func getTestWorkspaces(t *testing.T, c *http.Client, url string) (string, bool) {
+	t.Helper()
+	re := new(WorkspaceList)
+	modified, e := doGetWithHeader(c, CacheNameDevopAdmin, url, nil, http.StatusOK, re)
+	if e != nil || len(re.Items) != 1 {
+		t.Fatalf("get failed, %+v, %v", re, e)
+	}
+	return re.Items[0].Name, modified
+}
+
+func TestValidatorsStageCommitDiscard(t *testing.T) {
+	srv := newTestETagServer()
+	defer srv.Close()
+	vc := newValidatorCache()
+	c := withValidators(srv.Client(), vc)
+	if validatorsOf(c) != vc {
+		t.Fatalf("client should carry its validators")
+	}
+
+	// staged only, the next request is not conditional
+	if name, modified := getTestWorkspaces(t, c, srv.URL); name != "v1" || !modified {
+		t.Fatalf("expect v1 modified, got %s %v", name, modified)
+	}
+	getTestWorkspaces(t, c, srv.URL)
+	if n := srv.conditionals(); n != 0 {
+		t.Fatalf("staged validators should not be used, got %d conditional requests", n)
+	}
+
+	// committed, not modified is answered from the last body
+	vc.commit()
+	if name, modified := getTestWorkspaces(t, c, srv.URL); name != "v1" || modified {
+		t.Fatalf("expect v1 not modified, got %s %v", name, modified)
+	}
+	if n := srv.conditionals(); n != 1 {
+		t.Fatalf("expect 1 conditional request, got %d", n)
+	}
+
+	// discarded, the change is fetched again
+	srv.setVersion("2")
+	if name, modified := getTestWorkspaces(t, c, srv.URL); name != "v2" || !modified {
+		t.Fatalf("expect v2 modified, got %s %v", name, modified)
+	}
+	vc.discard()
+	if name, modified := getTestWorkspaces(t, c, srv.URL); name != "v2" || !modified {
+		t.Fatalf("expect v2 modified again after discard, got %s %v", name, modified)
+	}
+	vc.commit()
+	if _, modified := getTestWorkspaces(t, c, srv.URL); modified {
+		t.Fatalf("expect v2 not modified after commit")
+	}
+}
+
+func TestValidatorsOfPlainClient(t *testing.T) {
+	srv := newTestETagServer()
+	defer srv.Close()
+	c := srv.Client()
+	if validatorsOf(c) != nil {
+		t.Fatalf("plain client should have no validators")
+	}
+	for i := 0; i < 2; i++ {
+		if _, modified := getTestWorkspaces(t, c, srv.URL); !modified {
+			t.Fatalf("plain client should always get modified bodies")
+		}
+	}
+	if n := srv.conditionals(); n != 0 {
+		t.Fatalf("plain client should never be conditional, got %d", n)
+	}
+}
@@ -0,0 +1,93 @@
+package crd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	lazySyncPollInterval = 100 * time.Millisecond
+	lazyMinEvictInterval = time.Second
+)
+
+// LazyConfig makes sub cluster caches start on the first request of the
+// cluster, and stop after they are not requested for IdleTimeout.
+type LazyConfig struct {
+	// max time for a request to wait for the caches to be synced
+	SyncTimeout time.Duration
+	// caches not requested in it will be stopped, 0 means never
+	IdleTimeout time.Duration
+}
+
+func (lc *LazyConfig) IsEnabled() bool {
+	return lc != nil
+}
+
+// waitForSync returns when c has synced, SyncTimeout passed or ctx is done,
+// false if c is not synced
+func (lc *LazyConfig) waitForSync(ctx context.Context, c *subClusterCaches) bool {
+	if c.HasSynced() {
+		return true
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, lc.SyncTimeout)
+	defer cancel()
+	e := wait.PollUntil(lazySyncPollInterval, func() (bool, error) {
+		return c.HasSynced(), nil
+	}, ctx.Done())
+	if e != nil {
+		log.Printf("[cluster=%s] caches not synced in %v", c.name, lc.SyncTimeout)
+		return false
+	}
+	return true
+}
+
+// EnableLazyMode must be called before Run
+func (rc *ClusterResourcesCache) EnableLazyMode(lc LazyConfig) {
+	rc.lazy = &lc
+}
+
+func (rc *ClusterResourcesCache) runIdleEvictor(stopCh chan struct{}) {
+	idle := rc.lazy.IdleTimeout
+	if idle <= 0 {
+		return
+	}
+	interval := idle / 2
+	if interval < lazyMinEvictInterval {
+		interval = lazyMinEvictInterval
+	}
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-tk.C:
+			rc.evictIdleClusterCaches(idle)
+		}
+	}
+}
+
+func (rc *ClusterResourcesCache) evictIdleClusterCaches(idle time.Duration) {
+	var (
+		now         = time.Now()
+		cleanCaches []*subClusterCaches
+	)
+	rc.mLock.Lock()
+	for name, c := range rc.m {
+		if now.Sub(c.LastAccess()) > idle {
+			cleanCaches = append(cleanCaches, c)
+			delete(rc.m, name)
+		}
+	}
+	rc.mLock.Unlock()
+	for _, c := range cleanCaches {
+		log.Printf("[cluster=%s] caches idle since %v, stopping", c.name, c.LastAccess())
+		c.Stop()
+	}
+}
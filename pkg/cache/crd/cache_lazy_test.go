@@ -0,0 +1,93 @@
+package crd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	resv1b1 "github.com/caicloud/clientset/pkg/apis/resource/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+// newTestSubClusterCaches returns caches of name with a pod cache never run,
+// which is never synced
+func newTestSubClusterCaches(t *testing.T, name string, lastAccess time.Time) *subClusterCaches {
+	c, e := NewListWatchCache(&cache.ListWatch{}, &corev1.Pod{})
+	if e != nil {
+		t.Fatalf("new cache failed, %v", e)
+	}
+	return &subClusterCaches{
+		name:       name,
+		m:          map[string]*ListWatchCache{CacheNamePod: c},
+		stopChs:    map[string]chan struct{}{},
+		lastAccess: lastAccess.UnixNano(),
+	}
+}
+
+func TestEvictIdleClusterCaches(t *testing.T) {
+	idle := newTestSubClusterCaches(t, "idle", time.Now().Add(-time.Hour))
+	busy := newTestSubClusterCaches(t, "busy", time.Now())
+	rc := &ClusterResourcesCache{m: map[string]*subClusterCaches{"idle": idle, "busy": busy}}
+
+	rc.evictIdleClusterCaches(time.Minute)
+	if _, ok := rc.m["idle"]; ok || !idle.stopped {
+		t.Fatalf("idle caches should be evicted and stopped")
+	}
+	if _, ok := rc.m["busy"]; !ok || busy.stopped {
+		t.Fatalf("busy caches should be kept")
+	}
+}
+
+func TestGetSubClusterCachesNotSynced(t *testing.T) {
+	lastAccess := time.Now().Add(-time.Hour)
+	scc := newTestSubClusterCaches(t, "a", lastAccess)
+	rc := &ClusterResourcesCache{
+		m:    map[string]*subClusterCaches{"a": scc},
+		lazy: &LazyConfig{SyncTimeout: 50 * time.Millisecond},
+	}
+	c, fe := rc.GetSubClusterCaches(context.Background(), "a")
+	if c != nil || fe == nil || fe.Reason != errors.ErrorReasonClusterNotSynced {
+		t.Fatalf("expect not synced, got %v, %v", c, fe)
+	}
+	// a request keeps the caches from eviction even if it fails
+	if !scc.LastAccess().After(lastAccess) {
+		t.Fatalf("request should touch the caches")
+	}
+}
+
+func TestPeekClusterSummary(t *testing.T) {
+	lastAccess := time.Now().Add(-time.Hour)
+	scc := newTestSubClusterCaches(t, "a", lastAccess)
+	rc := &ClusterResourcesCache{
+		m:    map[string]*subClusterCaches{"a": scc},
+		lazy: &LazyConfig{SyncTimeout: time.Minute},
+	}
+	newCluster := func(name string, phase resv1b1.ClusterPhase) *resv1b1.Cluster {
+		return &resv1b1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     resv1b1.ClusterStatus{Phase: phase},
+		}
+	}
+	for _, cluster := range []*resv1b1.Cluster{
+		newCluster("a", ClusterStatusReady),
+		newCluster("b", ClusterStatusReady),
+	} {
+		cs := rc.peekClusterSummary(cluster)
+		if cs.Name != cluster.Name || !cs.Stale || cs.NodeNum != 0 {
+			t.Fatalf("expect stale name and phase only, got %+v", cs)
+		}
+	}
+	if cs := rc.peekClusterSummary(newCluster("c", ClusterStatusFailed)); cs.Stale {
+		t.Fatalf("cluster not cacheable should not be stale, got %+v", cs)
+	}
+	if _, ok := rc.m["b"]; ok || len(rc.m) != 1 {
+		t.Fatalf("peek should not start caches")
+	}
+	if !scc.LastAccess().Equal(time.Unix(0, lastAccess.UnixNano())) {
+		t.Fatalf("peek should not touch caches")
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"sync"
 
 	resv1b1 "github.com/caicloud/clientset/pkg/apis/resource/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 
@@ -19,6 +21,10 @@ import (
 type ListWatchCache struct {
 	indexer  cache.Indexer
 	informer cache.Controller
+	fallback *Fallback
+
+	// held while a delta is applied to indexer and handled
+	mutation sync.RWMutex
 }
 
 func NewListWatchCache(listWatcher cache.ListerWatcher, objType runtime.Object) (*ListWatchCache, error) {
@@ -27,18 +33,117 @@ func NewListWatchCache(listWatcher cache.ListerWatcher, objType runtime.Object)
 
 func NewListWatchCacheWithEventHandler(listWatcher cache.ListerWatcher, objType runtime.Object,
 	evHandler cache.ResourceEventHandler) (*ListWatchCache, error) {
+	return NewListWatchCacheWithOptions(listWatcher, objType, ListWatchCacheOptions{EvHandler: evHandler})
+}
+
+// NewListWatchCacheWithTransformer creates a cache which stores the objects
+// converted by transformer instead of the ones returned by listWatcher.
+func NewListWatchCacheWithTransformer(listWatcher cache.ListerWatcher, transformer *Transformer,
+	evHandler cache.ResourceEventHandler) (*ListWatchCache, error) {
+	if transformer == nil {
+		return nil, fmt.Errorf("bad transformer for ListWatchCache")
+	}
+	return NewListWatchCacheWithOptions(listWatcher, transformer.ObjType, ListWatchCacheOptions{
+		Transformer: transformer,
+		EvHandler:   evHandler,
+	})
+}
+
+// ListWatchCacheOptions are the optional parts of a ListWatchCache
+type ListWatchCacheOptions struct {
+	// objects are stored as converted by it, objType is ignored if set
+	Transformer *Transformer
+	Indexers    cache.Indexers
+	EvHandler   cache.ResourceEventHandler
+}
+
+func NewListWatchCacheWithOptions(listWatcher cache.ListerWatcher, objType runtime.Object,
+	opts ListWatchCacheOptions) (*ListWatchCache, error) {
 	if listWatcher == nil {
 		return nil, fmt.Errorf("nil ListerWatcher for ListWatchCache")
 	}
+	if t := opts.Transformer; t != nil {
+		if t.ObjType == nil || t.Transform == nil {
+			return nil, fmt.Errorf("bad transformer for ListWatchCache")
+		}
+		listWatcher = &transformListWatcher{
+			lw:          listWatcher,
+			transformer: t,
+		}
+		objType = t.ObjType
+	}
 	if objType == nil {
 		return nil, fmt.Errorf("nil runtime.Object for type")
 	}
-	indexer, informer := cache.NewIndexerInformer(listWatcher, objType, 0,
-		evHandler, cache.Indexers{})
-	return &ListWatchCache{
-		indexer:  indexer,
-		informer: informer,
-	}, nil
+	evHandler := opts.EvHandler
+	if evHandler == nil {
+		evHandler = cache.ResourceEventHandlerFuncs{}
+	}
+	indexers := opts.Indexers
+	if indexers == nil {
+		indexers = cache.Indexers{}
+	}
+	c := new(ListWatchCache)
+	c.indexer, c.informer = c.newIndexerInformer(listWatcher, objType, evHandler, indexers)
+	c.fallback = NewFallback(DefaultFallbackConfig(), c.HasSynced)
+	return c, nil
+}
+
+// newIndexerInformer is cache.NewIndexerInformer without resync, except that
+// every delta is stored and handled with mutation held, so the handler states
+// are consistent with the indexer in WithMutationLocked.
+func (c *ListWatchCache) newIndexerInformer(lw cache.ListerWatcher, objType runtime.Object,
+	h cache.ResourceEventHandler, indexers cache.Indexers) (cache.Indexer, cache.Controller) {
+	clientState := cache.NewIndexer(cache.DeletionHandlingMetaNamespaceKeyFunc, indexers)
+	fifo := cache.NewDeltaFIFO(cache.MetaNamespaceKeyFunc, clientState)
+	cfg := &cache.Config{
+		Queue:         fifo,
+		ListerWatcher: lw,
+		ObjectType:    objType,
+		RetryOnError:  false,
+		Process: func(obj interface{}) error {
+			// from oldest to newest
+			for _, d := range obj.(cache.Deltas) {
+				if e := c.processDelta(clientState, h, d); e != nil {
+					return e
+				}
+			}
+			return nil
+		},
+	}
+	return clientState, cache.New(cfg)
+}
+
+func (c *ListWatchCache) processDelta(clientState cache.Indexer, h cache.ResourceEventHandler, d cache.Delta) error {
+	c.mutation.Lock()
+	defer c.mutation.Unlock()
+	switch d.Type {
+	case cache.Sync, cache.Added, cache.Updated:
+		if old, exists, e := clientState.Get(d.Object); e == nil && exists {
+			if e := clientState.Update(d.Object); e != nil {
+				return e
+			}
+			h.OnUpdate(old, d.Object)
+		} else {
+			if e := clientState.Add(d.Object); e != nil {
+				return e
+			}
+			h.OnAdd(d.Object)
+		}
+	case cache.Deleted:
+		if e := clientState.Delete(d.Object); e != nil {
+			return e
+		}
+		h.OnDelete(d.Object)
+	}
+	return nil
+}
+
+// WithMutationLocked calls f with no delta being applied, f must not block
+func (c *ListWatchCache) WithMutationLocked(f func()) {
+	c.mutation.RLock()
+	defer c.mutation.RUnlock()
+	f()
 }
 
 func (c *ListWatchCache) Run(stopCh chan struct{}) {
@@ -67,10 +172,112 @@ func (c *ListWatchCache) List() (items []interface{}) {
 	return c.indexer.List()
 }
 
+// listInNamespace lists the objects of namespace by IndexNamespace if the cache
+// has it, callers still need to check the namespace if not
+func (c *ListWatchCache) listInNamespace(namespace string) []interface{} {
+	if len(namespace) == 0 {
+		return c.indexer.List()
+	}
+	if _, ok := c.indexer.GetIndexers()[IndexNamespace]; !ok {
+		return c.indexer.List()
+	}
+	items, e := c.indexer.ByIndex(IndexNamespace, namespace)
+	if e != nil {
+		return c.indexer.List()
+	}
+	if namespace == metav1.NamespaceDefault {
+		// objects without namespace are treated as default
+		more, _ := c.indexer.ByIndex(IndexNamespace, "")
+		items = append(items, more...)
+	}
+	return items
+}
+
+// byIndex returns nil if the cache has no indexer named indexName
+func (c *ListWatchCache) byIndex(indexName, value string) []interface{} {
+	items, e := c.indexer.ByIndex(indexName, value)
+	if e != nil {
+		return nil
+	}
+	return items
+}
+
 func (c *ListWatchCache) HasSynced() bool {
 	return c.informer.HasSynced()
 }
 
+// SetFallbackConfig must be called before the cache is used
+func (c *ListWatchCache) SetFallbackConfig(cfg FallbackConfig) {
+	c.fallback = NewFallback(cfg, c.HasSynced)
+}
+
+func (c *ListWatchCache) Fallback() *Fallback {
+	return c.fallback
+}
+
+// transform
+
+// Transformer converts listed and watched objects before they are stored,
+// ObjType is the type of the converted objects.
+type Transformer struct {
+	ObjType   runtime.Object
+	Transform func(obj runtime.Object) (runtime.Object, error)
+}
+
+type transformListWatcher struct {
+	lw          cache.ListerWatcher
+	transformer *Transformer
+}
+
+func (tlw *transformListWatcher) List(options metav1.ListOptions) (runtime.Object, error) {
+	list, e := tlw.lw.List(options)
+	if e != nil {
+		return nil, e
+	}
+	listMeta, e := meta.ListAccessor(list)
+	if e != nil {
+		return nil, e
+	}
+	items, e := meta.ExtractList(list)
+	if e != nil {
+		return nil, e
+	}
+	re := &metav1.List{
+		ListMeta: metav1.ListMeta{
+			ResourceVersion: listMeta.GetResourceVersion(),
+			SelfLink:        listMeta.GetSelfLink(),
+		},
+		Items: make([]runtime.RawExtension, 0, len(items)),
+	}
+	for _, item := range items {
+		obj, e := tlw.transformer.Transform(item)
+		if e != nil {
+			return nil, e
+		}
+		re.Items = append(re.Items, runtime.RawExtension{Object: obj})
+	}
+	return re, nil
+}
+
+func (tlw *transformListWatcher) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	w, e := tlw.lw.Watch(options)
+	if e != nil {
+		return nil, e
+	}
+	return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+		if in.Type == watch.Error || in.Object == nil {
+			return in, true
+		}
+		obj, e := tlw.transformer.Transform(in.Object)
+		if e != nil {
+			utilruntime.HandleError(fmt.Errorf("transform %v event failed, %v", in.Type, e))
+			return in, false
+		}
+		in.Object = obj
+		return in, true
+	}), nil
+}
+
 // kube client
 
 func ForceUpdateKubeClientCache(syncMap *sync.Map, cluster *resv1b1.Cluster) {
@@ -104,6 +311,14 @@ func DeleteInKubeClientCache(syncMap *sync.Map, key string) {
 
 // common
 
+// NamespaceKey is the indexer key of a namespaced object
+func NamespaceKey(namespace, name string) string {
+	if len(namespace) == 0 {
+		namespace = metav1.NamespaceDefault
+	}
+	return namespace + "/" + name
+}
+
 func CheckNamespace(obj metav1.Object, namespace string) bool {
 	if obj == nil {
 		return false
@@ -0,0 +1,177 @@
+package crd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	resv1b1 "github.com/caicloud/clientset/pkg/apis/resource/v1beta1"
+	"github.com/caicloud/nirvana/log"
+)
+
+// CacheSetConfig chooses the resource caches of every cluster.
+//
+// The caches of a cluster start from Enabled (the default caches if empty)
+// without Disabled, then every matched cluster rule adds its Enabled and
+// removes its Disabled in order.
+type CacheSetConfig struct {
+	Enabled  []string          `json:"enabled,omitempty"`
+	Disabled []string          `json:"disabled,omitempty"`
+	Clusters []ClusterCacheSet `json:"clusters,omitempty"`
+}
+
+// ClusterCacheSet matches clusters by name or by labels, at least one of them is required.
+type ClusterCacheSet struct {
+	Name     string            `json:"name,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Enabled  []string          `json:"enabled,omitempty"`
+	Disabled []string          `json:"disabled,omitempty"`
+}
+
+func NewDefaultCacheSetConfig() *CacheSetConfig {
+	return &CacheSetConfig{
+		Enabled: GetDefaultCacheNames(),
+	}
+}
+
+func ReadCacheSetConfigFile(fp string) (*CacheSetConfig, error) {
+	b, e := ioutil.ReadFile(fp)
+	if e != nil {
+		return nil, e
+	}
+	return ParseCacheSetConfig(b)
+}
+
+func ParseCacheSetConfig(b []byte) (*CacheSetConfig, error) {
+	csc := new(CacheSetConfig)
+	if e := json.Unmarshal(b, csc); e != nil {
+		return nil, e
+	}
+	if e := csc.Validate(); e != nil {
+		return nil, e
+	}
+	return csc, nil
+}
+
+func (csc *CacheSetConfig) Validate() error {
+	if e := checkCacheNames(csc.Enabled); e != nil {
+		return fmt.Errorf("global enabled, %v", e)
+	}
+	if e := checkCacheNames(csc.Disabled); e != nil {
+		return fmt.Errorf("global disabled, %v", e)
+	}
+	for i := range csc.Clusters {
+		ccs := &csc.Clusters[i]
+		if len(ccs.Name) == 0 && len(ccs.Labels) == 0 {
+			return fmt.Errorf("clusters[%d] has neither name nor labels", i)
+		}
+		if e := checkCacheNames(ccs.Enabled); e != nil {
+			return fmt.Errorf("clusters[%d] enabled, %v", i, e)
+		}
+		if e := checkCacheNames(ccs.Disabled); e != nil {
+			return fmt.Errorf("clusters[%d] disabled, %v", i, e)
+		}
+	}
+	return nil
+}
+
+// GetClusterCacheNames returns the cache names of cluster in registered order
+func (csc *CacheSetConfig) GetClusterCacheNames(cluster *resv1b1.Cluster) []string {
+	enabled := make(map[string]bool)
+	base := csc.Enabled
+	if len(base) == 0 {
+		base = defaultCacheNames
+	}
+	for _, name := range base {
+		enabled[name] = true
+	}
+	for _, name := range csc.Disabled {
+		delete(enabled, name)
+	}
+	for i := range csc.Clusters {
+		ccs := &csc.Clusters[i]
+		if !ccs.Match(cluster) {
+			continue
+		}
+		for _, name := range ccs.Enabled {
+			enabled[name] = true
+		}
+		for _, name := range ccs.Disabled {
+			delete(enabled, name)
+		}
+	}
+	re := make([]string, 0, len(enabled))
+	for i := range registeredConfigs {
+		if enabled[registeredConfigs[i].Name] {
+			re = append(re, registeredConfigs[i].Name)
+		}
+	}
+	return re
+}
+
+func (csc *CacheSetConfig) GetClusterConfigs(cluster *resv1b1.Cluster) []Config {
+	re, _ := GetConfigByNames(csc.GetClusterCacheNames(cluster))
+	return re
+}
+
+func (ccs *ClusterCacheSet) Match(cluster *resv1b1.Cluster) bool {
+	if cluster == nil {
+		return false
+	}
+	if len(ccs.Name) > 0 && ccs.Name != cluster.Name {
+		return false
+	}
+	for k, v := range ccs.Labels {
+		if cluster.Labels == nil || cluster.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func checkCacheNames(names []string) error {
+	for _, name := range names {
+		if !IsRegisteredCacheName(name) {
+			return fmt.Errorf("unknown cache name %s, should be one of %v", name, GetRegisteredCacheNames())
+		}
+	}
+	return nil
+}
+
+// reload
+
+// RunCacheSetReloader checks the config file every interval, and applies it
+// to rc when the content changed. Bad configs are logged and ignored.
+func RunCacheSetReloader(rc *ClusterResourcesCache, fp string, interval time.Duration, stopCh chan struct{}) {
+	if rc == nil || len(fp) == 0 || interval <= 0 {
+		return
+	}
+	last, _ := ioutil.ReadFile(fp)
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-tk.C:
+			b, e := ioutil.ReadFile(fp)
+			if e != nil {
+				log.Errorf("read cache set config %s failed, %v", fp, e)
+				continue
+			}
+			if bytes.Equal(b, last) {
+				continue
+			}
+			csc, e := ParseCacheSetConfig(b)
+			if e != nil {
+				log.Errorf("parse cache set config %s failed, %v", fp, e)
+				continue
+			}
+			last = b
+			log.Infof("cache set config %s changed, reloading", fp)
+			rc.SetCacheSetConfig(csc)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package crd
+
+import (
+	"sort"
+	"time"
+
+	resv1b1 "github.com/caicloud/clientset/pkg/apis/resource/v1beta1"
+)
+
+// Only the cluster summaries are kept in snapshot, the clusters themselves
+// carry kube credentials and must not be written to disk.
+
+type clusterSnapshot struct {
+	Clusters map[string]*ClusterSummary `json:"clusters"`
+}
+
+func (rc *ClusterResourcesCache) SnapshotName() string {
+	return "clusters"
+}
+
+// Snapshot saves the live summaries, and keeps the restored ones of the
+// clusters still existing but not synced yet
+func (rc *ClusterResourcesCache) Snapshot() (interface{}, bool) {
+	if !rc.cc.HasSynced() {
+		return nil, false
+	}
+	s := &clusterSnapshot{Clusters: make(map[string]*ClusterSummary)}
+	for _, obj := range rc.cc.List() {
+		cluster, _ := obj.(*resv1b1.Cluster)
+		if cluster == nil {
+			continue
+		}
+		rc.mLock.RLock()
+		c := rc.m[cluster.Name]
+		rc.mLock.RUnlock()
+		if c != nil && c.HasSynced() {
+			s.Clusters[cluster.Name] = c.Summary(cluster)
+		} else if cs := rc.getSnapshotSummary(cluster.Name); cs != nil {
+			cs.Stale = false
+			s.Clusters[cluster.Name] = cs
+		}
+	}
+	return s, true
+}
+
+func (rc *ClusterResourcesCache) Restore(savedAt time.Time, decode func(v interface{}) error) error {
+	s := new(clusterSnapshot)
+	if e := decode(s); e != nil {
+		return e
+	}
+	for name, cs := range s.Clusters {
+		if cs == nil {
+			delete(s.Clusters, name)
+		}
+	}
+	rc.snapshotLock.Lock()
+	rc.snapshot = s.Clusters
+	rc.snapshotLock.Unlock()
+	return nil
+}
+
+// getSnapshotSummary returns a stale copy of the restored summary
+func (rc *ClusterResourcesCache) getSnapshotSummary(clusterName string) *ClusterSummary {
+	rc.snapshotLock.RLock()
+	defer rc.snapshotLock.RUnlock()
+	cs := rc.snapshot[clusterName].DeepCopy()
+	if cs != nil {
+		cs.Stale = true
+	}
+	return cs
+}
+
+func (rc *ClusterResourcesCache) listSnapshotSummaries() []ClusterSummary {
+	rc.snapshotLock.RLock()
+	names := make([]string, 0, len(rc.snapshot))
+	for name := range rc.snapshot {
+		names = append(names, name)
+	}
+	rc.snapshotLock.RUnlock()
+	sort.Strings(names)
+	re := make([]ClusterSummary, 0, len(names))
+	for _, name := range names {
+		if cs := rc.getSnapshotSummary(name); cs != nil {
+			re = append(re, *cs)
+		}
+	}
+	return re
+}
@@ -1,14 +1,19 @@
 package crd
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	resv1b1 "github.com/caicloud/clientset/pkg/apis/resource/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/cache"
 
+	"github.com/caicloud/dashboard-admin/pkg/cache/notify"
 	"github.com/caicloud/dashboard-admin/pkg/errors"
 	"github.com/caicloud/dashboard-admin/pkg/kubernetes"
 )
@@ -18,12 +23,18 @@ import (
 type Config struct {
 	Name        string
 	Initializer func(kc kubernetes.Interface) (ListWatcher cache.ListerWatcher, ObjType runtime.Object)
+	// optional, objects are stored as converted by it
+	Transformer *Transformer
+	// optional, indexers of the cache
+	Indexers cache.Indexers
 }
 
 type ClusterResourcesCache struct {
 	// cluster cache
 	cc *ListWatchCache
-	ec *ClustersCache // export cluster cache
+	ec *ControlClusterCache // export cluster cache
+	// machines of the control cluster
+	mc *ListWatchCache
 
 	// cluster:caches
 	m     map[string]*subClusterCaches
@@ -32,22 +43,42 @@ type ClusterResourcesCache struct {
 	kc      kubernetes.Interface
 	kcCache *sync.Map // cluster:kc
 
-	configs []Config
+	cacheSet     *CacheSetConfig
+	cacheSetLock sync.RWMutex
+
+	lazy     *LazyConfig
+	fallback FallbackConfig
+	// of the counters of sub cluster caches
+	recountInterval time.Duration
+	notifier        notify.Notifier
+	events          *appEvents
+
+	// cluster:summary restored from snapshot
+	snapshot     map[string]*ClusterSummary
+	snapshotLock sync.RWMutex
 }
 
 func NewDefaultClusterResourcesCache(kc kubernetes.Interface) (rc *ClusterResourcesCache, e error) {
-	return NewClusterResourcesCache(kc, GetDefaultConfig())
+	return NewClusterResourcesCache(kc, NewDefaultCacheSetConfig())
 }
 
-func NewClusterResourcesCache(kc kubernetes.Interface, configs []Config) (rc *ClusterResourcesCache, e error) {
-	if e = checkCacheCreateConfigs(kc, configs); e != nil {
+func NewClusterResourcesCache(kc kubernetes.Interface, cacheSet *CacheSetConfig) (rc *ClusterResourcesCache, e error) {
+	if kc == nil {
+		return nil, errors.ErrVarKubeClientNil
+	}
+	if cacheSet == nil {
+		cacheSet = NewDefaultCacheSetConfig()
+	}
+	if e = cacheSet.Validate(); e != nil {
 		return nil, e
 	}
 	rc = &ClusterResourcesCache{
-		m:       make(map[string]*subClusterCaches),
-		kc:      kc,
-		kcCache: new(sync.Map),
-		configs: configs,
+		m:        make(map[string]*subClusterCaches),
+		kc:       kc,
+		kcCache:  new(sync.Map),
+		cacheSet: cacheSet,
+		fallback: DefaultFallbackConfig(),
+		events:   new(appEvents),
 	}
 	listWatcher, objType := GetClusterCacheConfig(kc)
 	rc.cc, e = NewListWatchCacheWithEventHandler(listWatcher, objType,
@@ -59,7 +90,11 @@ func NewClusterResourcesCache(kc kubernetes.Interface, configs []Config) (rc *Cl
 	if e != nil {
 		return nil, e
 	}
-	rc.ec = &ClustersCache{lwCache: rc.cc, kc: rc.kc, kcCache: rc.kcCache}
+	rc.ec = &ControlClusterCache{ClustersCache: &ClustersCache{lwCache: rc.cc, kc: rc.kc}, kcCache: rc.kcCache}
+	rc.mc, e = newConfigCache(kc, &machineCacheConfig, rc.fallback)
+	if e != nil {
+		return nil, e
+	}
 	return rc, nil
 }
 
@@ -67,18 +102,21 @@ func (rc *ClusterResourcesCache) handleClusterAdd(obj interface{}) {
 	cluster := obj.(*resv1b1.Cluster)
 	ForceUpdateKubeClientCache(rc.kcCache, cluster)
 	rc.updateClusterCache(cluster)
+	rc.notify(notify.Change{Topic: notify.TopicClusters, Cluster: cluster.Name})
 }
 
 func (rc *ClusterResourcesCache) handleClusterUpdate(oldObj, newObj interface{}) {
 	cluster := newObj.(*resv1b1.Cluster)
 	ForceUpdateKubeClientCache(rc.kcCache, cluster)
 	rc.updateClusterCache(cluster)
+	rc.notify(notify.Change{Topic: notify.TopicClusters, Cluster: cluster.Name})
 }
 
 func (rc *ClusterResourcesCache) handleClusterDelete(obj interface{}) {
 	cluster := obj.(*resv1b1.Cluster)
 	if cluster != nil {
 		DeleteInKubeClientCache(rc.kcCache, cluster.Name)
+		rc.notify(notify.Change{Topic: notify.TopicClusters, Cluster: cluster.Name})
 	}
 	rc.deleteClusterCache(cluster)
 }
@@ -88,47 +126,63 @@ func (rc *ClusterResourcesCache) updateClusterCache(cluster *resv1b1.Cluster) {
 	if cluster == nil {
 		return
 	}
-	switch cluster.Status.Phase {
-	case ClusterStatusNew:
+	if cluster.Status.Phase == ClusterStatusDeleting {
+		rc.deleteClusterCache(cluster)
 		return
-	case ClusterStatusInstallMaster:
+	}
+	if rc.lazy.IsEnabled() {
+		// started on the first request
 		return
+	}
+	rc.ensureClusterCache(context.TODO(), cluster)
+}
+
+func isClusterCacheable(phase resv1b1.ClusterPhase) bool {
+	switch phase {
 	case ClusterStatusInstallAddon:
 	case ClusterStatusReady:
-	case ClusterStatusFailed:
-		return
-	case ClusterStatusDeleting:
-		rc.deleteClusterCache(cluster)
-		return
 	default:
-		return
+		return false
 	}
-	// try client
-	kc, e := rc.ec.GetKubeClient(cluster.Name)
-	if e != nil {
-		return
+	return true
+}
+
+// ensureClusterCache returns the caches of cluster, creates and starts them if not exist
+func (rc *ClusterResourcesCache) ensureClusterCache(ctx context.Context, cluster *resv1b1.Cluster) *subClusterCaches {
+	if !isClusterCacheable(cluster.Status.Phase) {
+		return nil
 	}
 	// check exist
 	rc.mLock.RLock()
 	c := rc.m[cluster.Name]
 	rc.mLock.RUnlock()
 	if c != nil {
-		return
+		return c
+	}
+	// try client
+	kc, e := rc.ec.GetKubeClient(ctx, cluster.Name)
+	if e != nil {
+		return nil
 	}
 	// try set
 	rc.mLock.Lock()
 	defer rc.mLock.Unlock()
 	c = rc.m[cluster.Name]
 	if c != nil {
-		return
+		return c
 	}
-	c, e = NewSubClusterCaches(kc, rc.configs, cluster.Name)
+	c, e = NewSubClusterCaches(kc, rc.getClusterConfigs(cluster), cluster.Name, rc.fallback)
 	if e != nil {
-		return
+		log.Printf("[cluster=%s] create caches failed, %v", cluster.Name, e)
+		return nil
 	}
+	c.notifier = rc.notifier
+	c.events = rc.events
 	rc.m[cluster.Name] = c
 	go c.Start()
+	return c
 }
+
 func (rc *ClusterResourcesCache) deleteClusterCache(cluster *resv1b1.Cluster) {
 	var (
 		cleanCaches []*subClusterCaches
@@ -163,6 +217,13 @@ func (rc *ClusterResourcesCache) deleteClusterCache(cluster *resv1b1.Cluster) {
 }
 
 func (rc *ClusterResourcesCache) Run(stopCh chan struct{}) {
+	if rc.lazy.IsEnabled() {
+		go rc.runIdleEvictor(stopCh)
+	}
+	if rc.recountInterval > 0 {
+		go rc.runRecounter(stopCh)
+	}
+	go rc.mc.Run(stopCh)
 	rc.cc.Run(stopCh)
 	// cleanup
 	rc.mLock.Lock()
@@ -172,75 +233,264 @@ func (rc *ClusterResourcesCache) Run(stopCh chan struct{}) {
 	rc.mLock.Unlock()
 }
 
-func (rc *ClusterResourcesCache) GetAsClusterCache() *ClustersCache {
+func (rc *ClusterResourcesCache) GetAsClusterCache() *ControlClusterCache {
 	return rc.ec
 }
 
-func (rc *ClusterResourcesCache) GetSubClusterCaches(clusterName string) (*subClusterCaches, *errors.FormatError) {
+// GetMachinesCache returns the machines of the control cluster
+func (rc *ClusterResourcesCache) GetMachinesCache() *MachinesCache {
+	return &MachinesCache{lwCache: rc.mc, kc: rc.kc}
+}
+
+// GetSubClusterCaches returns the caches of cluster, in lazy mode it may
+// start them and wait for sync until ctx is done, and fails if they are not
+// synced by then rather than serve partial lists.
+func (rc *ClusterResourcesCache) GetSubClusterCaches(ctx context.Context, clusterName string) (*subClusterCaches, *errors.FormatError) {
 	rc.mLock.RLock()
 	c := rc.m[clusterName]
 	rc.mLock.RUnlock()
-	if c != nil {
-		return c, nil
+	if c == nil {
+		item, _, _ := rc.cc.indexer.GetByKey(clusterName)
+		cluster, _ := item.(*resv1b1.Cluster)
+		if cluster == nil {
+			return nil, errors.NewError().SetErrorObjectNotFound(clusterName, nil)
+		}
+		if rc.lazy.IsEnabled() {
+			c = rc.ensureClusterCache(ctx, cluster)
+		}
+		if c == nil {
+			return nil, errors.NewError().SetErrorClusterNotReady(clusterName, string(cluster.Status.Phase))
+		}
+	}
+	c.touch()
+	if rc.lazy.IsEnabled() && !rc.lazy.waitForSync(ctx, c) {
+		return nil, errors.NewError().SetErrorClusterNotSynced(clusterName)
+	}
+	return c, nil
+}
+
+// GetStartedSubClusterCaches returns the caches of cluster if they are
+// running and synced. It neither starts nor touches them, for the readers
+// going through all the clusters.
+func (rc *ClusterResourcesCache) GetStartedSubClusterCaches(clusterName string) (*subClusterCaches, bool) {
+	rc.mLock.RLock()
+	c := rc.m[clusterName]
+	rc.mLock.RUnlock()
+	if c == nil || !c.HasSynced() {
+		return nil, false
 	}
-	item, _, _ := rc.cc.indexer.GetByKey(clusterName)
-	if item != nil && item.(*resv1b1.Cluster) != nil {
-		cluster := item.(*resv1b1.Cluster)
-		return nil, errors.NewError().SetErrorClusterNotReady(clusterName, string(cluster.Status.Phase))
+	return c, true
+}
+
+// GetCluster returns the cluster from cache, or from the source before the
+// cache is synced if the fallback allows
+func (rc *ClusterResourcesCache) GetCluster(ctx context.Context, clusterName string) (*resv1b1.Cluster, *errors.FormatError) {
+	cluster, e := CacheGetCluster(ctx, clusterName, rc.cc, rc.kc)
+	if e != nil {
+		if fe, ok := e.(*errors.FormatError); ok {
+			return nil, fe
+		}
+		if kubernetes.IsNotFound(e) {
+			return nil, errors.NewError().SetErrorObjectNotFound(clusterName, e)
+		}
+		return nil, errors.NewError().SetErrorInternalServerError(e)
 	}
-	return nil, errors.NewError().SetErrorObjectNotFound(clusterName, nil)
+	return cluster, nil
+}
+
+// SubCacheState is the sync state of a cache of a cluster
+type SubCacheState struct {
+	Name   string `json:"name"`
+	Synced bool   `json:"synced"`
+}
+
+// GetSubCacheStates returns the states of the enabled caches of the cluster
+// sorted by name, nil if they are not started. It does not start them in
+// lazy mode.
+func (rc *ClusterResourcesCache) GetSubCacheStates(clusterName string) []SubCacheState {
+	rc.mLock.RLock()
+	c := rc.m[clusterName]
+	rc.mLock.RUnlock()
+	if c == nil {
+		return nil
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	re := make([]SubCacheState, 0, len(c.m))
+	for name, lc := range c.m {
+		re = append(re, SubCacheState{Name: name, Synced: lc.HasSynced()})
+	}
+	sort.Slice(re, func(i, j int) bool {
+		return re[i].Name < re[j].Name
+	})
+	return re
+}
+
+// SetFallbackConfig sets the live fallback of the cluster cache and the
+// sub cluster caches, it must be called before Run
+func (rc *ClusterResourcesCache) SetFallbackConfig(cfg FallbackConfig) {
+	rc.fallback = cfg
+	rc.cc.SetFallbackConfig(cfg)
+	rc.mc.SetFallbackConfig(cfg)
+}
+
+// cache set
+
+func (rc *ClusterResourcesCache) GetCacheSetConfig() *CacheSetConfig {
+	rc.cacheSetLock.RLock()
+	defer rc.cacheSetLock.RUnlock()
+	return rc.cacheSet
+}
+
+// SetCacheSetConfig replaces the cache set config and applies it to the running clusters
+func (rc *ClusterResourcesCache) SetCacheSetConfig(cacheSet *CacheSetConfig) {
+	if cacheSet == nil {
+		return
+	}
+	rc.cacheSetLock.Lock()
+	rc.cacheSet = cacheSet
+	rc.cacheSetLock.Unlock()
+
+	rc.mLock.RLock()
+	defer rc.mLock.RUnlock()
+	for name, c := range rc.m {
+		item, _, _ := rc.cc.indexer.GetByKey(name)
+		cluster, _ := item.(*resv1b1.Cluster)
+		if cluster == nil {
+			continue
+		}
+		if e := c.Apply(rc.getClusterConfigs(cluster)); e != nil {
+			log.Printf("[cluster=%s] apply cache set failed, %v", name, e)
+		}
+	}
+}
+
+func (rc *ClusterResourcesCache) getClusterConfigs(cluster *resv1b1.Cluster) []Config {
+	return rc.GetCacheSetConfig().GetClusterConfigs(cluster)
 }
 
 // sub cluster
 
 type subClusterCaches struct {
-	name   string
-	kc     kubernetes.Interface
-	m      map[string]*ListWatchCache
-	stopCh chan struct{}
+	name     string
+	kc       kubernetes.Interface
+	fallback FallbackConfig
+	counters *ClusterCounters
+	notifier notify.Notifier
+	events   *appEvents
 
-	hasSynced []func() bool
+	lock    sync.RWMutex
+	m       map[string]*ListWatchCache
+	stopChs map[string]chan struct{}
+	started bool
+	stopped bool
+
+	lastAccess int64 // unix nano
 }
 
-func NewSubClusterCaches(kc kubernetes.Interface, configs []Config, clusterName string) (*subClusterCaches, error) {
+func NewSubClusterCaches(kc kubernetes.Interface, configs []Config, clusterName string,
+	fallback FallbackConfig) (*subClusterCaches, error) {
 	e := checkCacheCreateConfigs(kc, configs)
 	if e != nil {
 		return nil, e
 	}
 	scc := &subClusterCaches{
-		name:   clusterName,
-		kc:     kc,
-		m:      make(map[string]*ListWatchCache, len(configs)),
-		stopCh: make(chan struct{}),
+		name:     clusterName,
+		kc:       kc,
+		fallback: fallback,
+		m:        make(map[string]*ListWatchCache, len(configs)),
+		stopChs:  make(map[string]chan struct{}, len(configs)),
+
+		lastAccess: time.Now().UnixNano(),
 	}
+	scc.counters = NewClusterCounters(clusterName, scc.tenantOfNamespace)
 	for i := range configs {
-		name := configs[i].Name
-		listWatcher, objType := configs[i].Initializer(kc)
-		c, e := NewListWatchCache(listWatcher, objType)
+		c, e := newConfigCacheWithEventHandler(kc, &configs[i], fallback, scc.eventHandler(configs[i].Name))
 		if e != nil {
 			return nil, e
 		}
-		scc.m[name] = c
-		scc.hasSynced = append(scc.hasSynced, c.HasSynced)
+		scc.m[configs[i].Name] = c
 	}
 	return scc, nil
 }
 
 func (scc *subClusterCaches) Start() {
-	for k, c := range scc.m {
-		if c != nil {
-			go func(name string) {
-				logPrefix := fmt.Sprintf("[cluster=%s][cache=%s]", scc.name, name)
-				log.Printf("%s start", logPrefix)
-				c.Run(scc.stopCh)
-				log.Printf("%s stopped", logPrefix)
-			}(k)
+	scc.lock.Lock()
+	defer scc.lock.Unlock()
+	if scc.started || scc.stopped {
+		return
+	}
+	scc.started = true
+	for name, c := range scc.m {
+		scc.runCache(name, c)
+	}
+}
+
+// runCache must be called with lock held
+func (scc *subClusterCaches) runCache(name string, c *ListWatchCache) {
+	stopCh := make(chan struct{})
+	scc.stopChs[name] = stopCh
+	go func() {
+		logPrefix := fmt.Sprintf("[cluster=%s][cache=%s]", scc.name, name)
+		log.Printf("%s start", logPrefix)
+		c.Run(stopCh)
+		log.Printf("%s stopped", logPrefix)
+	}()
+}
+
+// stopCache must be called with lock held
+func (scc *subClusterCaches) stopCache(name string) {
+	if stopCh, ok := scc.stopChs[name]; ok {
+		close(stopCh)
+		delete(scc.stopChs, name)
+	}
+}
+
+// Apply starts the caches newly in configs and stops the ones not in configs
+func (scc *subClusterCaches) Apply(configs []Config) error {
+	if e := checkCacheCreateConfigs(scc.kc, configs); e != nil {
+		return e
+	}
+	want := make(map[string]*Config, len(configs))
+	for i := range configs {
+		want[configs[i].Name] = &configs[i]
+	}
+
+	scc.lock.Lock()
+	defer scc.lock.Unlock()
+	if scc.stopped {
+		return nil
+	}
+	for name := range scc.m {
+		if _, ok := want[name]; !ok {
+			scc.stopCache(name)
+			delete(scc.m, name)
+			scc.counters.Reset(name)
+			log.Printf("[cluster=%s][cache=%s] disabled", scc.name, name)
 		}
 	}
+	for name, config := range want {
+		if _, ok := scc.m[name]; ok {
+			continue
+		}
+		c, e := newConfigCacheWithEventHandler(scc.kc, config, scc.fallback, scc.eventHandler(name))
+		if e != nil {
+			return e
+		}
+		scc.m[name] = c
+		log.Printf("[cluster=%s][cache=%s] enabled", scc.name, name)
+		if scc.started {
+			scc.runCache(name, c)
+		}
+	}
+	return nil
 }
+
 func (scc *subClusterCaches) HasSynced() bool {
-	for _, f := range scc.hasSynced {
-		if !f() {
+	scc.lock.RLock()
+	defer scc.lock.RUnlock()
+	for _, c := range scc.m {
+		if !c.HasSynced() {
 			return false
 		}
 	}
@@ -248,16 +498,65 @@ func (scc *subClusterCaches) HasSynced() bool {
 }
 
 func (scc *subClusterCaches) Stop() {
-	close(scc.stopCh)
+	scc.lock.Lock()
+	defer scc.lock.Unlock()
+	if scc.stopped {
+		return
+	}
+	scc.stopped = true
+	for name := range scc.stopChs {
+		scc.stopCache(name)
+	}
+}
+
+func (scc *subClusterCaches) touch() {
+	atomic.StoreInt64(&scc.lastAccess, time.Now().UnixNano())
+}
+
+func (scc *subClusterCaches) LastAccess() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&scc.lastAccess))
 }
 
 func (scc *subClusterCaches) GetCoreCache(name string) (*ListWatchCache, bool) {
+	scc.lock.RLock()
+	defer scc.lock.RUnlock()
 	c, ok := scc.m[name]
 	return c, ok
 }
 
+// CacheNames returns the names of the enabled caches
+func (scc *subClusterCaches) CacheNames() []string {
+	scc.lock.RLock()
+	defer scc.lock.RUnlock()
+	re := make([]string, 0, len(scc.m))
+	for name := range scc.m {
+		re = append(re, name)
+	}
+	sort.Strings(re)
+	return re
+}
+
 // config
 
+func newConfigCache(kc kubernetes.Interface, config *Config, fallback FallbackConfig) (c *ListWatchCache, e error) {
+	return newConfigCacheWithEventHandler(kc, config, fallback, nil)
+}
+
+func newConfigCacheWithEventHandler(kc kubernetes.Interface, config *Config, fallback FallbackConfig,
+	evHandler cache.ResourceEventHandler) (c *ListWatchCache, e error) {
+	listWatcher, objType := config.Initializer(kc)
+	c, e = NewListWatchCacheWithOptions(listWatcher, objType, ListWatchCacheOptions{
+		Transformer: config.Transformer,
+		Indexers:    config.Indexers,
+		EvHandler:   evHandler,
+	})
+	if e != nil {
+		return nil, e
+	}
+	c.SetFallbackConfig(fallback)
+	return c, nil
+}
+
 func checkCacheCreateConfigs(kc kubernetes.Interface, configs []Config) error {
 	if kc == nil {
 		return errors.ErrVarKubeClientNil
@@ -270,6 +569,7 @@ func checkCacheCreateConfigs(kc kubernetes.Interface, configs []Config) error {
 		if _, ok := m[configs[i].Name]; ok {
 			return errors.ErrVarDuplicatedConfig
 		}
+		m[configs[i].Name] = struct{}{}
 	}
 	return nil
 }
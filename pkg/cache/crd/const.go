@@ -1,6 +1,10 @@
 package crd
 
+//go:generate go run maker/maker.go -m maker/manifest.json -o .
+
 import (
+	"fmt"
+
 	resv1b1 "github.com/caicloud/clientset/pkg/apis/resource/v1beta1"
 )
 
@@ -14,22 +18,46 @@ const (
 	ClusterStatusDeleting      resv1b1.ClusterPhase = "Deleting"
 )
 
-var defaultConfig = []Config{
-	{Name: CacheNameNode, Initializer: GetNodeCacheConfig},
-	{Name: CacheNameRelease, Initializer: GetReleaseCacheConfig},
-	{Name: CacheNamePod, Initializer: GetPodCacheConfig},
-	{Name: CacheNameClusterQuota, Initializer: GetClusterQuotaCacheConfig},
-	{Name: CacheNameTenant, Initializer: GetTenantCacheConfig},
-	{Name: CacheNamePartition, Initializer: GetPartitionCacheConfig},
-	{Name: CacheNameStorageClass, Initializer: GetStorageClassCacheConfig},
-	{Name: CacheNameLoadBalancer, Initializer: GetLoadBalancerCacheConfig},
+func GetDefaultConfig() []Config {
+	re, _ := GetConfigByNames(defaultCacheNames)
+	return re
+}
+
+func GetDefaultCacheNames() []string {
+	return append([]string(nil), defaultCacheNames...)
 }
 
-func GetDefaultConfig() []Config {
-	re := make([]Config, len(defaultConfig))
-	for i := range defaultConfig {
-		re[i].Name = defaultConfig[i].Name
-		re[i].Initializer = defaultConfig[i].Initializer
+func GetRegisteredCacheNames() []string {
+	re := make([]string, len(registeredConfigs))
+	for i := range registeredConfigs {
+		re[i] = registeredConfigs[i].Name
 	}
 	return re
 }
+
+func IsRegisteredCacheName(name string) bool {
+	_, ok := getRegisteredConfig(name)
+	return ok
+}
+
+// GetConfigByNames returns copies of the registered configs in the order of names
+func GetConfigByNames(names []string) ([]Config, error) {
+	re := make([]Config, 0, len(names))
+	for _, name := range names {
+		c, ok := getRegisteredConfig(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown cache name %s, should be one of %v", name, GetRegisteredCacheNames())
+		}
+		re = append(re, c)
+	}
+	return re, nil
+}
+
+func getRegisteredConfig(name string) (Config, bool) {
+	for i := range registeredConfigs {
+		if registeredConfigs[i].Name == name {
+			return registeredConfigs[i], true
+		}
+	}
+	return Config{}, false
+}
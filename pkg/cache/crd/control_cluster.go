@@ -0,0 +1,79 @@
+package crd
+
+import (
+	"context"
+	"sync"
+
+	resv1b1 "github.com/caicloud/clientset/pkg/apis/resource/v1beta1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/caicloud/dashboard-admin/pkg/kubernetes"
+)
+
+// ControlClusterCache is the cluster cache of the control cluster,
+// with the kube clients of the clusters.
+type ControlClusterCache struct {
+	*ClustersCache
+	kcCache *sync.Map
+}
+
+func NewControlClusterCache(kc kubernetes.Interface) (*ControlClusterCache, error) {
+	cc := &ControlClusterCache{
+		kcCache: new(sync.Map),
+	}
+	listWatcher, objType := GetClusterCacheConfig(kc)
+	c, e := NewListWatchCacheWithEventHandler(listWatcher, objType, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			cluster := obj.(*resv1b1.Cluster)
+			ForceUpdateKubeClientCache(cc.kcCache, cluster)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			cluster := newObj.(*resv1b1.Cluster)
+			ForceUpdateKubeClientCache(cc.kcCache, cluster)
+		},
+		DeleteFunc: func(obj interface{}) {
+			cluster := obj.(*resv1b1.Cluster)
+			if cluster != nil {
+				DeleteInKubeClientCache(cc.kcCache, cluster.Name)
+			}
+		},
+	})
+	if e != nil {
+		return nil, e
+	}
+	cc.ClustersCache = &ClustersCache{lwCache: c, kc: kc}
+	return cc, nil
+}
+
+func (cc *ControlClusterCache) GetKubeClient(ctx context.Context, key string) (kubernetes.Interface, error) {
+	return CacheGetKubeClient(ctx, key, cc.kcCache, cc.Get)
+}
+
+func CacheGetKubeClient(ctx context.Context, clusterName string, syncMap *sync.Map,
+	clusterGetter func(ctx context.Context, clusterName string) (*resv1b1.Cluster, error)) (kc kubernetes.Interface, e error) {
+	if syncMap != nil {
+		obj, ok := syncMap.Load(clusterName)
+		if ok && obj != nil {
+			kc, ok = obj.(kubernetes.Interface)
+			if ok && kc != nil {
+				return kc, nil
+			}
+		}
+	}
+
+	cluster, e := clusterGetter(ctx, clusterName)
+	if e != nil {
+		return nil, e
+	}
+	// if cluster.Status.Phase != kubernetes.ClusterStatusReady { // TODO is OK to remove this check?
+	// 	return nil, errors.ErrVarClusterNotReady
+	// }
+	kc, e = kubernetes.NewClientFromRestConfig(GetKubeConfigFromClusterAuth(&cluster.Spec.Auth))
+	if e != nil {
+		return nil, e
+	}
+	if syncMap != nil {
+		syncMap.Store(clusterName, kc)
+	}
+	return kc, nil
+}
@@ -0,0 +1,542 @@
+package crd
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	rlsv1a1 "github.com/caicloud/clientset/pkg/apis/release/v1alpha1"
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Counters are kept by the event handlers of the sub cluster caches, so the
+// summaries are read without walking the caches. A periodic recount walks
+// the caches to find and correct the drift of counters.
+
+type ReleaseState string
+
+const (
+	ReleaseStateAvailable   ReleaseState = "Available"
+	ReleaseStateProgressing ReleaseState = "Progressing"
+	ReleaseStateFailure     ReleaseState = "Failure"
+	ReleaseStateUnknown     ReleaseState = "Unknown"
+)
+
+// GetReleaseState picks the worst true condition of release
+func GetReleaseState(release *rlsv1a1.Release) ReleaseState {
+	state := ReleaseStateUnknown
+	for _, cond := range release.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case rlsv1a1.ReleaseFailure:
+			return ReleaseStateFailure
+		case rlsv1a1.ReleaseProgressing:
+			state = ReleaseStateProgressing
+		case rlsv1a1.ReleaseAvailable:
+			if state == ReleaseStateUnknown {
+				state = ReleaseStateAvailable
+			}
+		}
+	}
+	return state
+}
+
+// Counts of a cluster or of a tenant in it, nodes are only counted for cluster
+type Counts struct {
+	NodeNum      int                 `json:"nodeNum"`
+	ReadyNodeNum int                 `json:"readyNodeNum"`
+	Capacity     corev1.ResourceList `json:"capacity,omitempty"`
+	Allocatable  corev1.ResourceList `json:"allocatable,omitempty"`
+
+	PodNum    int                     `json:"podNum"`
+	PodPhases map[corev1.PodPhase]int `json:"podPhases,omitempty"`
+	// of the pods not terminated
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	Limits   corev1.ResourceList `json:"limits,omitempty"`
+
+	ReleaseNum    int                  `json:"releaseNum"`
+	ReleaseStates map[ReleaseState]int `json:"releaseStates,omitempty"`
+}
+
+func NewCounts() *Counts {
+	return &Counts{
+		Capacity:      corev1.ResourceList{},
+		Allocatable:   corev1.ResourceList{},
+		PodPhases:     make(map[corev1.PodPhase]int),
+		Requests:      corev1.ResourceList{},
+		Limits:        corev1.ResourceList{},
+		ReleaseStates: make(map[ReleaseState]int),
+	}
+}
+
+// Add adds delta to c, or subtracts it if negative
+func (c *Counts) Add(delta *Counts, negative bool) {
+	sign := 1
+	if negative {
+		sign = -1
+	}
+	c.NodeNum += sign * delta.NodeNum
+	c.ReadyNodeNum += sign * delta.ReadyNodeNum
+	c.PodNum += sign * delta.PodNum
+	c.ReleaseNum += sign * delta.ReleaseNum
+	for k, v := range delta.PodPhases {
+		n := c.PodPhases[k] + sign*v
+		if n == 0 {
+			delete(c.PodPhases, k)
+		} else {
+			c.PodPhases[k] = n
+		}
+	}
+	for k, v := range delta.ReleaseStates {
+		n := c.ReleaseStates[k] + sign*v
+		if n == 0 {
+			delete(c.ReleaseStates, k)
+		} else {
+			c.ReleaseStates[k] = n
+		}
+	}
+	addResources(c.Capacity, delta.Capacity, negative)
+	addResources(c.Allocatable, delta.Allocatable, negative)
+	addResources(c.Requests, delta.Requests, negative)
+	addResources(c.Limits, delta.Limits, negative)
+}
+
+func (c *Counts) IsZero() bool {
+	return c.Equal(NewCounts())
+}
+
+func (c *Counts) Equal(o *Counts) bool {
+	return c.NodeNum == o.NodeNum && c.ReadyNodeNum == o.ReadyNodeNum &&
+		c.PodNum == o.PodNum && c.ReleaseNum == o.ReleaseNum &&
+		reflect.DeepEqual(c.PodPhases, o.PodPhases) &&
+		reflect.DeepEqual(c.ReleaseStates, o.ReleaseStates) &&
+		equalResources(c.Capacity, o.Capacity) && equalResources(c.Allocatable, o.Allocatable) &&
+		equalResources(c.Requests, o.Requests) && equalResources(c.Limits, o.Limits)
+}
+
+func (c *Counts) DeepCopy() *Counts {
+	out := NewCounts()
+	out.Add(c, false)
+	return out
+}
+
+func addResources(dst, delta corev1.ResourceList, negative bool) {
+	for name, q := range delta {
+		cur := dst[name]
+		if negative {
+			cur.Sub(q)
+		} else {
+			cur.Add(q)
+		}
+		if cur.IsZero() {
+			delete(dst, name)
+		} else {
+			dst[name] = cur
+		}
+	}
+}
+
+func equalResources(a, b corev1.ResourceList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, q := range a {
+		if o, ok := b[name]; !ok || q.Cmp(o) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// object contributions
+
+// counter converts an object of a cache to its counts and the namespace
+// used to find its tenant, namespace is ignored by cluster level objects
+type counter func(obj interface{}) (namespace string, delta *Counts)
+
+var cacheCounters = map[string]counter{
+	CacheNameNode:    countNode,
+	CacheNamePod:     countPod,
+	CacheNameRelease: countRelease,
+}
+
+func countNode(obj interface{}) (string, *Counts) {
+	node := ToNodeProjection(obj)
+	if node == nil {
+		return "", nil
+	}
+	delta := NewCounts()
+	delta.NodeNum = 1
+	if node.IsReady() {
+		delta.ReadyNodeNum = 1
+	}
+	AddResourceList(delta.Capacity, node.Capacity)
+	AddResourceList(delta.Allocatable, node.Allocatable)
+	return "", delta
+}
+
+func countPod(obj interface{}) (string, *Counts) {
+	pod := ToPodProjection(obj)
+	if pod == nil {
+		return "", nil
+	}
+	delta := NewCounts()
+	delta.PodNum = 1
+	delta.PodPhases[pod.Phase] = 1
+	if !pod.IsTerminated() {
+		delta.Requests = pod.ResourceRequests()
+		delta.Limits = pod.ResourceLimits()
+	}
+	return pod.Namespace, delta
+}
+
+func countRelease(obj interface{}) (string, *Counts) {
+	release, _ := obj.(*rlsv1a1.Release)
+	if release == nil {
+		return "", nil
+	}
+	delta := NewCounts()
+	delta.ReleaseNum = 1
+	delta.ReleaseStates[GetReleaseState(release)] = 1
+	return release.Namespace, delta
+}
+
+// cluster counters
+
+type partCounts struct {
+	cluster *Counts
+	tenants map[string]*Counts
+}
+
+func newPartCounts() *partCounts {
+	return &partCounts{
+		cluster: NewCounts(),
+		tenants: make(map[string]*Counts),
+	}
+}
+
+func (pc *partCounts) add(tenant string, delta *Counts, negative bool) {
+	pc.cluster.Add(delta, negative)
+	if len(tenant) == 0 {
+		return
+	}
+	tc := pc.tenants[tenant]
+	if tc == nil {
+		tc = NewCounts()
+		pc.tenants[tenant] = tc
+	}
+	tc.Add(delta, negative)
+	if tc.IsZero() {
+		delete(pc.tenants, tenant)
+	}
+}
+
+// ClusterCounters keeps the counts of the caches of a cluster by cache name
+type ClusterCounters struct {
+	name     string
+	tenantOf func(namespace string) string
+
+	lock       sync.RWMutex
+	parts      map[string]*partCounts
+	lastReport *RecountReport
+}
+
+func NewClusterCounters(name string, tenantOf func(namespace string) string) *ClusterCounters {
+	return &ClusterCounters{
+		name:     name,
+		tenantOf: tenantOf,
+		parts:    make(map[string]*partCounts),
+	}
+}
+
+// EventHandler returns the handler of cache name, or nil if it is not counted
+func (cc *ClusterCounters) EventHandler(name string) cache.ResourceEventHandler {
+	count, ok := cacheCounters[name]
+	if !ok {
+		return nil
+	}
+	apply := func(obj interface{}, negative bool) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		namespace, delta := count(obj)
+		if delta == nil {
+			return
+		}
+		tenant := ""
+		if len(namespace) > 0 {
+			tenant = cc.tenantOf(namespace)
+		}
+		cc.lock.Lock()
+		defer cc.lock.Unlock()
+		pc := cc.parts[name]
+		if pc == nil {
+			pc = newPartCounts()
+			cc.parts[name] = pc
+		}
+		pc.add(tenant, delta, negative)
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			apply(obj, false)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			apply(oldObj, true)
+			apply(newObj, false)
+		},
+		DeleteFunc: func(obj interface{}) {
+			apply(obj, true)
+		},
+	}
+}
+
+// Reset drops the counts of a disabled cache
+func (cc *ClusterCounters) Reset(name string) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	delete(cc.parts, name)
+}
+
+func (cc *ClusterCounters) Cluster() *Counts {
+	cc.lock.RLock()
+	defer cc.lock.RUnlock()
+	re := NewCounts()
+	for _, pc := range cc.parts {
+		re.Add(pc.cluster, false)
+	}
+	return re
+}
+
+func (cc *ClusterCounters) Tenant(tenant string) *Counts {
+	cc.lock.RLock()
+	defer cc.lock.RUnlock()
+	re := NewCounts()
+	for _, pc := range cc.parts {
+		if tc := pc.tenants[tenant]; tc != nil {
+			re.Add(tc, false)
+		}
+	}
+	return re
+}
+
+func (cc *ClusterCounters) Tenants() map[string]*Counts {
+	cc.lock.RLock()
+	defer cc.lock.RUnlock()
+	re := make(map[string]*Counts)
+	for _, pc := range cc.parts {
+		for tenant, tc := range pc.tenants {
+			if re[tenant] == nil {
+				re[tenant] = NewCounts()
+			}
+			re[tenant].Add(tc, false)
+		}
+	}
+	return re
+}
+
+// recount
+
+type RecountReport struct {
+	Cluster string    `json:"cluster"`
+	Time    time.Time `json:"time"`
+	Drifts  []Drift   `json:"drifts,omitempty"`
+}
+
+// Drift is a difference between the counters and the recount, Tenant is empty for cluster level
+type Drift struct {
+	Cache    string  `json:"cache"`
+	Tenant   string  `json:"tenant,omitempty"`
+	Counters *Counts `json:"counters"`
+	Recount  *Counts `json:"recount"`
+}
+
+func (d *Drift) String() string {
+	scope := "cluster"
+	if len(d.Tenant) > 0 {
+		scope = "tenant " + d.Tenant
+	}
+	return fmt.Sprintf("%s of %s: counters %+v, recount %+v", d.Cache, scope, *d.Counters, *d.Recount)
+}
+
+// Recount walks the counted caches, replaces the counters with the results
+// and reports the differences
+func (cc *ClusterCounters) Recount(caches map[string]*ListWatchCache) *RecountReport {
+	report := &RecountReport{Cluster: cc.name, Time: time.Now()}
+	names := make([]string, 0, len(caches))
+	for name := range caches {
+		if _, ok := cacheCounters[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	cc.lock.Lock()
+	for name := range cc.parts {
+		if _, ok := caches[name]; !ok {
+			// left by a disabled cache
+			delete(cc.parts, name)
+		}
+	}
+	cc.lock.Unlock()
+	for _, name := range names {
+		c, count := caches[name], cacheCounters[name]
+		c.WithMutationLocked(func() {
+			fresh := newPartCounts()
+			for _, obj := range c.indexer.List() {
+				namespace, delta := count(obj)
+				if delta == nil {
+					continue
+				}
+				tenant := ""
+				if len(namespace) > 0 {
+					tenant = cc.tenantOf(namespace)
+				}
+				fresh.add(tenant, delta, false)
+			}
+			cc.lock.Lock()
+			old := cc.parts[name]
+			if old == nil {
+				old = newPartCounts()
+			}
+			cc.parts[name] = fresh
+			cc.lock.Unlock()
+			report.Drifts = append(report.Drifts, diffPartCounts(name, old, fresh)...)
+		})
+	}
+	cc.lock.Lock()
+	cc.lastReport = report
+	cc.lock.Unlock()
+	return report
+}
+
+func (cc *ClusterCounters) LastRecountReport() *RecountReport {
+	cc.lock.RLock()
+	defer cc.lock.RUnlock()
+	return cc.lastReport
+}
+
+func diffPartCounts(name string, old, fresh *partCounts) (re []Drift) {
+	if !old.cluster.Equal(fresh.cluster) {
+		re = append(re, Drift{Cache: name, Counters: old.cluster, Recount: fresh.cluster})
+	}
+	tenants := make(map[string]bool)
+	for tenant := range old.tenants {
+		tenants[tenant] = true
+	}
+	for tenant := range fresh.tenants {
+		tenants[tenant] = true
+	}
+	sorted := make([]string, 0, len(tenants))
+	for tenant := range tenants {
+		sorted = append(sorted, tenant)
+	}
+	sort.Strings(sorted)
+	for _, tenant := range sorted {
+		o, f := old.tenants[tenant], fresh.tenants[tenant]
+		if o == nil {
+			o = NewCounts()
+		}
+		if f == nil {
+			f = NewCounts()
+		}
+		if !o.Equal(f) {
+			re = append(re, Drift{Cache: name, Tenant: tenant, Counters: o, Recount: f})
+		}
+	}
+	return re
+}
+
+// tenant
+
+// tenantOfNamespace finds the tenant by the partition of the same name as
+// namespace. It reads the indexer only, the handlers must not wait for the
+// source. Objects counted before their partition is synced are moved to the
+// tenant by the recount.
+func (scc *subClusterCaches) tenantOfNamespace(namespace string) string {
+	c, ok := scc.GetCoreCache(CacheNamePartition)
+	if !ok {
+		return ""
+	}
+	obj, exist, e := c.indexer.GetByKey(namespace)
+	if !exist || e != nil {
+		return ""
+	}
+	partition, _ := obj.(*tntv1al.Partition)
+	if partition == nil {
+		return ""
+	}
+	return partition.Spec.Tenant
+}
+
+// TenantOfNamespace finds the tenant of namespace from the cached partitions
+func (scc *subClusterCaches) TenantOfNamespace(namespace string) string {
+	return scc.tenantOfNamespace(namespace)
+}
+
+// Recount recounts the enabled caches of the cluster
+func (scc *subClusterCaches) Recount() *RecountReport {
+	scc.lock.RLock()
+	caches := make(map[string]*ListWatchCache, len(scc.m))
+	for name, c := range scc.m {
+		caches[name] = c
+	}
+	scc.lock.RUnlock()
+	return scc.counters.Recount(caches)
+}
+
+// SetRecountInterval must be called before Run, 0 means never
+func (rc *ClusterResourcesCache) SetRecountInterval(interval time.Duration) {
+	rc.recountInterval = interval
+}
+
+func (rc *ClusterResourcesCache) runRecounter(stopCh chan struct{}) {
+	tk := time.NewTicker(rc.recountInterval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-tk.C:
+			rc.recount()
+		}
+	}
+}
+
+func (rc *ClusterResourcesCache) recount() {
+	rc.mLock.RLock()
+	caches := make([]*subClusterCaches, 0, len(rc.m))
+	for _, c := range rc.m {
+		caches = append(caches, c)
+	}
+	rc.mLock.RUnlock()
+	for _, c := range caches {
+		// counters of caches not synced are still growing
+		if !c.HasSynced() {
+			continue
+		}
+		report := c.Recount()
+		for i := range report.Drifts {
+			log.Printf("[cluster=%s] counter drift, %s", c.name, report.Drifts[i].String())
+		}
+	}
+}
+
+// GetRecountReports returns the last recount reports of the running cluster caches
+func (rc *ClusterResourcesCache) GetRecountReports() []RecountReport {
+	rc.mLock.RLock()
+	defer rc.mLock.RUnlock()
+	re := make([]RecountReport, 0, len(rc.m))
+	for _, c := range rc.m {
+		if report := c.counters.LastRecountReport(); report != nil {
+			re = append(re, *report)
+		}
+	}
+	sort.Slice(re, func(i, j int) bool { return re[i].Cluster < re[j].Cluster })
+	return re
+}
@@ -0,0 +1,102 @@
+package crd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	rlsv1a1 "github.com/caicloud/clientset/pkg/apis/release/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// appEventsSize is the number of the latest app events kept
+const appEventsSize = 256
+
+const (
+	AppEventCreate = "create"
+	AppEventUpdate = "update"
+	AppEventDelete = "delete"
+)
+
+// AppEvent is a release created, rolled out or deleted, seen by the release
+// cache after it synced, empty Tenant means a namespace of no tenant
+type AppEvent struct {
+	Type      string
+	Failed    bool
+	Time      time.Time
+	Tenant    string
+	Cluster   string
+	Namespace string
+	Name      string
+	Message   string
+}
+
+// appEvents keeps the latest app events of all clusters in memory, they are
+// lost on restart
+type appEvents struct {
+	lock   sync.RWMutex
+	events []AppEvent
+}
+
+func (ae *appEvents) add(ev AppEvent) {
+	ae.lock.Lock()
+	defer ae.lock.Unlock()
+	ae.events = append(ae.events, ev)
+	if len(ae.events) > appEventsSize {
+		ae.events = append([]AppEvent(nil), ae.events[len(ae.events)-appEventsSize:]...)
+	}
+}
+
+// list returns the events, the latest first
+func (ae *appEvents) list() []AppEvent {
+	ae.lock.RLock()
+	defer ae.lock.RUnlock()
+	re := make([]AppEvent, len(ae.events))
+	for i, ev := range ae.events {
+		re[len(re)-1-i] = ev
+	}
+	return re
+}
+
+// ListAppEvents returns the latest app events of all clusters, the latest first
+func (rc *ClusterResourcesCache) ListAppEvents() []AppEvent {
+	return rc.events.list()
+}
+
+// newAppEvent returns the event of a release changed from old to cur, nil if
+// it is not worth one, like a status update of the same version
+func newAppEvent(eventType string, old, cur *rlsv1a1.Release) *AppEvent {
+	if cur == nil {
+		return nil
+	}
+	failed := releaseFailure(cur)
+	if eventType == AppEventUpdate {
+		if old == nil || old.ResourceVersion == cur.ResourceVersion ||
+			(old.Status.Version == cur.Status.Version && (releaseFailure(old) != nil) == (failed != nil)) {
+			return nil
+		}
+	}
+	ev := &AppEvent{
+		Type:      eventType,
+		Failed:    failed != nil,
+		Time:      time.Now(),
+		Namespace: cur.Namespace,
+		Name:      cur.Name,
+		Message:   fmt.Sprintf("release %s/%s version %d", cur.Namespace, cur.Name, cur.Status.Version),
+	}
+	if failed != nil && len(failed.Message) > 0 {
+		ev.Message = fmt.Sprintf("%s, %s", ev.Message, failed.Message)
+	}
+	return ev
+}
+
+// releaseFailure returns the failure condition of release if it is failed
+func releaseFailure(release *rlsv1a1.Release) *rlsv1a1.ReleaseCondition {
+	for i := range release.Status.Conditions {
+		cond := &release.Status.Conditions[i]
+		if cond.Type == rlsv1a1.ReleaseFailure && cond.Status == corev1.ConditionTrue {
+			return cond
+		}
+	}
+	return nil
+}
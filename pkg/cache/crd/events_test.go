@@ -0,0 +1,168 @@
+package crd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	rlsv1a1 "github.com/caicloud/clientset/pkg/apis/release/v1alpha1"
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/caicloud/dashboard-admin/pkg/cache/notify"
+)
+
+type testNotifier struct {
+	changes []notify.Change
+}
+
+func (n *testNotifier) Notify(change notify.Change) {
+	n.changes = append(n.changes, change)
+}
+
+func (n *testNotifier) take() []notify.Change {
+	re := n.changes
+	n.changes = nil
+	return re
+}
+
+func newTestRelease(namespace string, version int32, failed bool) *rlsv1a1.Release {
+	release := &rlsv1a1.Release{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "r", ResourceVersion: "1"},
+		Status:     rlsv1a1.ReleaseStatus{Version: version},
+	}
+	if failed {
+		release.ResourceVersion = "2"
+		release.Status.Conditions = []rlsv1a1.ReleaseCondition{{
+			Type: rlsv1a1.ReleaseFailure, Status: corev1.ConditionTrue, Message: "crash",
+		}}
+	}
+	return release
+}
+
+// newTestEventCaches returns caches of cluster c1 with a synced empty release
+// cache and the partition of ns-a owned by tenant a
+func newTestEventCaches(t *testing.T, stopCh chan struct{}) (*subClusterCaches, *testNotifier) {
+	rlc, e := NewListWatchCache(&cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return &rlsv1a1.ReleaseList{}, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return watch.NewFake(), nil
+		},
+	}, &rlsv1a1.Release{})
+	if e != nil {
+		t.Fatalf("new release cache failed, %v", e)
+	}
+	ptc, e := NewListWatchCache(&cache.ListWatch{}, &tntv1al.Partition{})
+	if e != nil {
+		t.Fatalf("new partition cache failed, %v", e)
+	}
+	ptc.indexer.Add(&tntv1al.Partition{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns-a"},
+		Spec:       tntv1al.PartitionSpec{Tenant: "a"},
+	})
+	go rlc.Run(stopCh)
+	deadline := time.Now().Add(5 * time.Second)
+	for !rlc.HasSynced() {
+		if time.Now().After(deadline) {
+			t.Fatalf("release cache not synced")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	n := &testNotifier{}
+	scc := &subClusterCaches{
+		name:     "c1",
+		m:        map[string]*ListWatchCache{CacheNameRelease: rlc, CacheNamePartition: ptc},
+		stopChs:  map[string]chan struct{}{},
+		notifier: n,
+		events:   new(appEvents),
+	}
+	scc.counters = NewClusterCounters(scc.name, scc.tenantOfNamespace)
+	return scc, n
+}
+
+func TestEventHandlerTenantChanges(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	scc, n := newTestEventCaches(t, stopCh)
+	h := scc.eventHandler(CacheNameRelease)
+
+	h.OnAdd(newTestRelease("ns-a", 1, false))
+	if got, want := n.take(), []notify.Change{
+		{Topic: notify.TopicApps, Tenant: "a", Cluster: "c1"},
+		{Topic: notify.TopicClusters, Tenant: "a", Cluster: "c1"},
+		{Topic: notify.TopicEvents, Tenant: "a", Cluster: "c1"},
+	}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expect changes %+v, got %+v", want, got)
+	}
+	// of no tenant, only the cluster level topics
+	h.OnAdd(newTestRelease("ns-x", 1, false))
+	if got, want := n.take(), []notify.Change{
+		{Topic: notify.TopicClusters, Cluster: "c1"},
+	}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expect changes %+v, got %+v", want, got)
+	}
+}
+
+func TestEventHandlerAppEvents(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	scc, n := newTestEventCaches(t, stopCh)
+	h := scc.eventHandler(CacheNameRelease)
+
+	v1 := newTestRelease("ns-a", 1, false)
+	h.OnAdd(v1)
+	// resync and status updates of the same version are not events
+	h.OnUpdate(v1, v1)
+	v1Status := v1.DeepCopy()
+	v1Status.ResourceVersion = "3"
+	h.OnUpdate(v1, v1Status)
+	n.take()
+	v1Failed := newTestRelease("ns-a", 1, true)
+	h.OnUpdate(v1Status, v1Failed)
+	h.OnDelete(cache.DeletedFinalStateUnknown{Key: "ns-a/r", Obj: v1Failed})
+
+	evs := scc.events.list()
+	if len(evs) != 3 {
+		t.Fatalf("expect 3 events, got %+v", evs)
+	}
+	for i, want := range []struct {
+		eventType string
+		failed    bool
+	}{
+		{AppEventDelete, true},
+		{AppEventUpdate, true},
+		{AppEventCreate, false},
+	} {
+		ev := evs[i]
+		if ev.Type != want.eventType || ev.Failed != want.failed || ev.Tenant != "a" || ev.Cluster != "c1" || ev.Name != "r" {
+			t.Fatalf("unexpected event %d %+v", i, ev)
+		}
+	}
+	if evs[1].Message != "release ns-a/r version 1, crash" {
+		t.Fatalf("unexpected message %q", evs[1].Message)
+	}
+	for _, change := range n.take() {
+		if change.Topic == notify.TopicEvents {
+			return
+		}
+	}
+	t.Fatalf("expect the events topic changed")
+}
+
+func TestAppEventsTrimmed(t *testing.T) {
+	ae := new(appEvents)
+	for i := 0; i < appEventsSize+2; i++ {
+		ae.add(AppEvent{Time: time.Unix(int64(i), 0)})
+	}
+	evs := ae.list()
+	if len(evs) != appEventsSize || evs[0].Time.Unix() != appEventsSize+1 || evs[len(evs)-1].Time.Unix() != 2 {
+		t.Fatalf("expect the latest %d events, the latest first, got %d from %v to %v",
+			appEventsSize, len(evs), evs[0].Time, evs[len(evs)-1].Time)
+	}
+}
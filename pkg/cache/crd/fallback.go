@@ -0,0 +1,169 @@
+package crd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+// FallbackPolicy decides when a typed cache reads the source on a cache miss.
+type FallbackPolicy string
+
+const (
+	// FallbackNever never reads the source, misses are not found
+	FallbackNever FallbackPolicy = "never"
+	// FallbackUnsynced reads the source only before the cache has synced
+	FallbackUnsynced FallbackPolicy = "unsynced"
+	// FallbackAlways reads the source on every miss or empty list
+	FallbackAlways FallbackPolicy = "always"
+)
+
+const (
+	DefaultFallbackPolicy  = FallbackUnsynced
+	DefaultFallbackQPS     = 1
+	DefaultFallbackBurst   = 5
+	DefaultFallbackTimeout = 10 * time.Second
+)
+
+var ErrVarFallbackDisabled = fmt.Errorf("live fallback disabled")
+
+func ParseFallbackPolicy(s string) (FallbackPolicy, error) {
+	switch p := FallbackPolicy(s); p {
+	case FallbackNever, FallbackUnsynced, FallbackAlways:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown fallback policy %s, should be one of %v",
+		s, []FallbackPolicy{FallbackNever, FallbackUnsynced, FallbackAlways})
+}
+
+// FallbackConfig applies to every cluster and resource separately.
+type FallbackConfig struct {
+	Policy FallbackPolicy
+	// live requests per second and burst, QPS <= 0 means no limit
+	QPS   float64
+	Burst int
+	// max time of a live request, which is shared by all waiting callers
+	Timeout time.Duration
+}
+
+func DefaultFallbackConfig() FallbackConfig {
+	return FallbackConfig{
+		Policy:  DefaultFallbackPolicy,
+		QPS:     DefaultFallbackQPS,
+		Burst:   DefaultFallbackBurst,
+		Timeout: DefaultFallbackTimeout,
+	}
+}
+
+// Fallback rate-limits and coalesces the live requests of one cache.
+type Fallback struct {
+	cfg       FallbackConfig
+	limiter   *rate.Limiter
+	hasSynced func() bool
+
+	lock  sync.Mutex
+	calls map[string]*fallbackCall
+}
+
+type fallbackCall struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+}
+
+func NewFallback(cfg FallbackConfig, hasSynced func() bool) *Fallback {
+	f := &Fallback{
+		cfg:       cfg,
+		hasSynced: hasSynced,
+		calls:     make(map[string]*fallbackCall),
+	}
+	if cfg.QPS > 0 {
+		burst := cfg.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		f.limiter = rate.NewLimiter(rate.Limit(cfg.QPS), burst)
+	}
+	if f.cfg.Timeout <= 0 {
+		f.cfg.Timeout = DefaultFallbackTimeout
+	}
+	return f
+}
+
+// Enabled tells whether a miss should be read from source now
+func (f *Fallback) Enabled() bool {
+	switch f.cfg.Policy {
+	case FallbackNever:
+		return false
+	case FallbackUnsynced:
+		return f.hasSynced == nil || !f.hasSynced()
+	}
+	return true
+}
+
+// Do runs fn once for all concurrent callers with the same key, every caller
+// returns when fn is done or its ctx is done.
+func (f *Fallback) Do(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	if !f.Enabled() {
+		return nil, ErrVarFallbackDisabled
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	f.lock.Lock()
+	c, ok := f.calls[key]
+	if !ok {
+		c = &fallbackCall{done: make(chan struct{})}
+		f.calls[key] = c
+		go f.call(key, c, fn)
+	}
+	f.lock.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *Fallback) call(key string, c *fallbackCall, fn func() (interface{}, error)) {
+	defer func() {
+		f.lock.Lock()
+		delete(f.calls, key)
+		f.lock.Unlock()
+		close(c.done)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), f.cfg.Timeout)
+	defer cancel()
+	if f.limiter != nil {
+		if e := f.limiter.Wait(ctx); e != nil {
+			c.err = fmt.Errorf("live request %s rate limited, %v", key, e)
+			return
+		}
+	}
+	resultCh := make(chan fallbackCall, 1)
+	go func() {
+		val, e := fn()
+		resultCh <- fallbackCall{val: val, err: e}
+	}()
+	select {
+	case re := <-resultCh:
+		c.val, c.err = re.val, re.err
+	case <-ctx.Done():
+		c.val, c.err = nil, fmt.Errorf("live request %s timeout in %v", key, f.cfg.Timeout)
+	}
+}
+
+// FallbackGetError turns a disabled fallback of get into not found
+func FallbackGetError(key string, e error) error {
+	if e == ErrVarFallbackDisabled {
+		return errors.NewError().SetErrorObjectNotFound(key, e)
+	}
+	return e
+}
@@ -0,0 +1,30 @@
+package crd
+
+import (
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Indexers of the caches, enabled per resource in maker/manifest.json
+
+const (
+	IndexNamespace       = cache.NamespaceIndex
+	IndexPodNodeName     = "nodeName"
+	IndexPartitionTenant = "tenant"
+)
+
+func PodNodeNameIndexFunc(obj interface{}) ([]string, error) {
+	pod := ToPodProjection(obj)
+	if pod == nil || len(pod.NodeName) == 0 {
+		return nil, nil
+	}
+	return []string{pod.NodeName}, nil
+}
+
+func PartitionTenantIndexFunc(obj interface{}) ([]string, error) {
+	partition, _ := obj.(*tntv1al.Partition)
+	if partition == nil || len(partition.Spec.Tenant) == 0 {
+		return nil, nil
+	}
+	return []string{partition.Spec.Tenant}, nil
+}
@@ -4,77 +4,252 @@ import (
 	"bytes"
 	"encoding/json"
 	"flag"
+	"fmt"
+	"go/format"
 	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
 	"text/template"
+	"unicode"
 )
 
 var (
-	configPath string
-	outputPath string
+	manifestPath string
+	outputDir    string
+	checkOnly    bool
 )
 
 func main() {
-	flag.StringVar(&configPath, "c", "", "config file path")
-	flag.StringVar(&outputPath, "o", "out.go", "output file path")
+	flag.StringVar(&manifestPath, "m", "manifest.json", "manifest file path")
+	flag.StringVar(&outputDir, "o", "..", "output directory")
+	flag.BoolVar(&checkOnly, "check", false, "check generated files are up to date instead of writing them")
 	flag.Parse()
 
-	log.Printf("configPath: %v", configPath)
-	log.Printf("outputPath: %v", outputPath)
-
-	t, e := template.New("xxx").Parse(Template)
+	m, e := ReadManifestFile(manifestPath)
 	if e != nil {
-		log.Fatalf("new template failed, %v", e)
+		log.Fatalf("read manifest file failed, %v", e)
 	}
-
-	c, e := ReadConfigFile(configPath)
+	files, e := Render(m)
 	if e != nil {
-		log.Fatalf("read config file failed, %v", e)
+		log.Fatalf("render failed, %v", e)
 	}
 
-	b := new(bytes.Buffer)
-
-	e = t.Execute(b, c)
-	if e != nil {
-		log.Fatalf("execute failed, %v", e)
+	var drifted []string
+	for _, f := range files {
+		fp := filepath.Join(outputDir, f.Name)
+		if checkOnly {
+			b, e := ioutil.ReadFile(fp)
+			if e != nil || !bytes.Equal(b, f.Content) {
+				drifted = append(drifted, fp)
+			}
+			continue
+		}
+		log.Printf("%s to %s", manifestPath, fp)
+		if e = ioutil.WriteFile(fp, f.Content, 0664); e != nil {
+			log.Fatalf("write file failed, %v", e)
+		}
 	}
-
-	e = ioutil.WriteFile(outputPath, b.Bytes(), 0664)
-	if e != nil {
-		log.Fatalf("write file failed, %v", e)
+	if len(drifted) > 0 {
+		for _, fp := range drifted {
+			log.Printf("%s is out of date", fp)
+		}
+		log.Printf("run go generate in %s to update", outputDir)
+		os.Exit(1)
 	}
 }
 
-type Config struct {
+// manifest
+
+type Manifest struct {
+	Package   string     `json:"package"`
+	Resources []Resource `json:"resources"`
+}
+
+type Resource struct {
 	Name    string `json:"name"`
 	Plural  string `json:"plural"`
 	VarName string `json:"varName"`
 
-	IsNonNamespaced bool `json:"isNonNamespaced"`
+	// rest path, empty group is core
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+
+	Namespaced bool `json:"namespaced"`
 
 	ImportPath string `json:"importPath"`
 	ImportName string `json:"importName"`
 
 	ClientPkgName string `json:"clientPkgName"`
 	ClientName    string `json:"clientName"`
+
+	// stored as {{.Name}}Projection, converted by {{.Name}}Transformer
+	Projection bool      `json:"projection"`
+	Indexers   []Indexer `json:"indexers"`
+
+	// cache of the control cluster, not registered for sub clusters
+	ControlCluster bool `json:"controlCluster"`
+	// enabled when no cache set config is given
+	Default bool `json:"default"`
+}
+
+type Indexer struct {
+	Name string `json:"name"`
+	Func string `json:"func"`
 }
 
-func ReadConfigFile(fp string) (*Config, error) {
+func ReadManifestFile(fp string) (*Manifest, error) {
 	b, e := ioutil.ReadFile(fp)
 	if e != nil {
 		return nil, e
 	}
-	c := new(Config)
-	e = json.Unmarshal(b, c)
+	m := new(Manifest)
+	e = json.Unmarshal(b, m)
 	if e != nil {
 		return nil, e
 	}
-	return c, nil
+	return m, m.Validate()
+}
+
+func (m *Manifest) Validate() error {
+	if len(m.Package) == 0 {
+		return fmt.Errorf("empty package")
+	}
+	names := make(map[string]bool, len(m.Resources))
+	for i := range m.Resources {
+		r := &m.Resources[i]
+		if len(r.Name) == 0 || len(r.Plural) == 0 || len(r.VarName) == 0 {
+			return fmt.Errorf("resources[%d] needs name, plural and varName", i)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("duplicated resource %s", r.Name)
+		}
+		names[r.Name] = true
+		if len(r.Version) == 0 || len(r.Resource) == 0 {
+			return fmt.Errorf("resource %s needs version and resource", r.Name)
+		}
+		if len(r.ImportPath) == 0 || len(r.ImportName) == 0 || len(r.ClientPkgName) == 0 || len(r.ClientName) == 0 {
+			return fmt.Errorf("resource %s needs importPath, importName, clientPkgName and clientName", r.Name)
+		}
+		if r.ControlCluster && (r.Default || len(r.Indexers) > 0) {
+			return fmt.Errorf("control cluster resource %s can not be default or indexed", r.Name)
+		}
+		for _, idx := range r.Indexers {
+			if len(idx.Name) == 0 || len(idx.Func) == 0 {
+				return fmt.Errorf("resource %s has indexer without name or func", r.Name)
+			}
+		}
+	}
+	return nil
 }
 
-const Template = `package crd
+// FileName is the snake case of Plural
+func (r *Resource) FileName() string {
+	b := new(bytes.Buffer)
+	for i, c := range r.Plural {
+		if unicode.IsUpper(c) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// ItemType is the type stored in the cache
+func (r *Resource) ItemType() string {
+	if r.Projection {
+		return r.Name + "Projection"
+	}
+	return r.SourceType()
+}
+
+// SourceType is the type returned by the client
+func (r *Resource) SourceType() string {
+	return r.ImportName + "." + r.Name
+}
+
+// Client is the typed client expression, ns is ignored if not namespaced
+func (r *Resource) Client(ns string) string {
+	if !r.Namespaced {
+		ns = ""
+	}
+	return fmt.Sprintf("kc.%s().%s(%s)", r.ClientPkgName, r.ClientName, ns)
+}
+
+func (r *Resource) APIPath() string {
+	if len(r.Group) == 0 {
+		return "/api/" + r.Version
+	}
+	return "/apis/" + r.Group + "/" + r.Version
+}
+
+// render
+
+type File struct {
+	Name    string
+	Content []byte
+}
+
+func Render(m *Manifest) ([]File, error) {
+	var files []File
+	for i := range m.Resources {
+		r := &m.Resources[i]
+		f, e := renderFile(r.FileName()+".go", resourceTemplate, struct {
+			Package string
+			*Resource
+		}{m.Package, r})
+		if e != nil {
+			return nil, fmt.Errorf("resource %s, %v", r.Name, e)
+		}
+		files = append(files, f)
+	}
+	for _, t := range []struct {
+		name string
+		tpl  *template.Template
+	}{
+		{"registry.go", registryTemplate},
+		{"registry_test.go", testTemplate},
+	} {
+		f, e := renderFile(t.name, t.tpl, m)
+		if e != nil {
+			return nil, fmt.Errorf("%s, %v", t.name, e)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+func renderFile(name string, t *template.Template, data interface{}) (File, error) {
+	b := new(bytes.Buffer)
+	if e := t.Execute(b, data); e != nil {
+		return File{}, e
+	}
+	src, e := format.Source(b.Bytes())
+	if e != nil {
+		return File{}, fmt.Errorf("format failed, %v", e)
+	}
+	return File{Name: name, Content: src}, nil
+}
+
+var (
+	resourceTemplate = template.Must(template.New("resource").Parse(ResourceTemplate))
+	registryTemplate = template.Must(template.New("registry").Parse(RegistryTemplate))
+	testTemplate     = template.Must(template.New("test").Parse(TestTemplate))
+)
+
+const header = `// Code generated by maker from manifest.json. DO NOT EDIT.
+
+`
+
+const ResourceTemplate = header + `package {{.Package}}
 
 import (
+	"context"
+
 	{{.ImportName}} "{{.ImportPath}}"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
@@ -90,25 +265,39 @@ const (
 	CacheName{{.Name}} = "{{.Name}}"
 )
 
+var {{.VarName}}CacheConfig = Config{
+	Name:        CacheName{{.Name}},
+	Initializer: Get{{.Name}}CacheConfig,
+{{- if .Projection}}
+	Transformer: {{.Name}}Transformer,
+{{- end}}
+{{- if .Indexers}}
+	Indexers: cache.Indexers{
+{{- range .Indexers}}
+		{{.Name}}: {{.Func}},
+{{- end}}
+	},
+{{- end}}
+}
+{{if not .ControlCluster}}
 func (scc *subClusterCaches) Get{{.Name}}Cache() (*{{.Plural}}Cache, bool) {
 	return scc.GetAs{{.Name}}Cache(CacheName{{.Name}})
 }
 func (scc *subClusterCaches) GetAs{{.Name}}Cache(name string) (*{{.Plural}}Cache, bool) {
-	c, ok := scc.m[name]
+	c, ok := scc.GetCoreCache(name)
 	if ok {
 		return &{{.Plural}}Cache{lwCache: c, kc: scc.kc}, true
 	}
 	return nil, false
 }
-
+{{end}}
 type {{.Plural}}Cache struct {
 	lwCache *ListWatchCache
 	kc      kubernetes.Interface
 }
 
 func New{{.Plural}}Cache(kc kubernetes.Interface) (*{{.Plural}}Cache, error) {
-	listWatcher, objType := Get{{.Name}}CacheConfig(kc)
-	c, e := NewListWatchCache(listWatcher, objType)
+	c, e := newConfigCache(kc, &{{.VarName}}CacheConfig, DefaultFallbackConfig())
 	if e != nil {
 		return nil, e
 	}
@@ -121,25 +310,38 @@ func New{{.Plural}}Cache(kc kubernetes.Interface) (*{{.Plural}}Cache, error) {
 func (tc *{{.Plural}}Cache) Run(stopCh chan struct{}) {
 	tc.lwCache.Run(stopCh)
 }
-{{if .IsNonNamespaced}}
-func (tc *{{.Plural}}Cache) Get(key string) (*{{.ImportName}}.{{.Name}}, error) {
-	return CacheGet{{.Name}}(key, tc.lwCache.indexer, tc.kc)
+{{if .Namespaced}}
+func (tc *{{.Plural}}Cache) Get(ctx context.Context, namespace, key string) (*{{.ItemType}}, error) {
+	return CacheGet{{.Name}}(ctx, namespace, key, tc.lwCache, tc.kc)
 }
-func (tc *{{.Plural}}Cache) List() ([]{{.ImportName}}.{{.Name}}, error) {
-	return CacheList{{.Plural}}(tc.lwCache.indexer, tc.kc)
+func (tc *{{.Plural}}Cache) List(ctx context.Context, namespace string) ([]{{.ItemType}}, error) {
+	return CacheList{{.Plural}}(ctx, namespace, tc.lwCache, tc.kc)
 }
-func (tc *{{.Plural}}Cache) ListCachePointer() (re []*{{.ImportName}}.{{.Name}}) {
-	return CacheList{{.Plural}}Pointer(tc.lwCache.indexer, tc.kc)
+func (tc *{{.Plural}}Cache) ListCachePointer(ctx context.Context, namespace string) (re []*{{.ItemType}}) {
+	return CacheList{{.Plural}}Pointer(ctx, namespace, tc.lwCache, tc.kc)
 }
 {{else}}
-func (tc *{{.Plural}}Cache) Get(namespace, key string) (*{{.ImportName}}.{{.Name}}, error) {
-	return CacheGet{{.Name}}(namespace, key, tc.lwCache.indexer, tc.kc)
+func (tc *{{.Plural}}Cache) Get(ctx context.Context, key string) (*{{.ItemType}}, error) {
+	return CacheGet{{.Name}}(ctx, key, tc.lwCache, tc.kc)
+}
+func (tc *{{.Plural}}Cache) List(ctx context.Context) ([]{{.ItemType}}, error) {
+	return CacheList{{.Plural}}(ctx, tc.lwCache, tc.kc)
 }
-func (tc *{{.Plural}}Cache) List(namespace string) ([]{{.ImportName}}.{{.Name}}, error) {
-	return CacheList{{.Plural}}(namespace, tc.lwCache.indexer, tc.kc)
+func (tc *{{.Plural}}Cache) ListCachePointer(ctx context.Context) (re []*{{.ItemType}}) {
+	return CacheList{{.Plural}}Pointer(ctx, tc.lwCache, tc.kc)
 }
-func (tc *{{.Plural}}Cache) ListCachePointer(namespace string) (re []*{{.ImportName}}.{{.Name}}) {
-	return CacheList{{.Plural}}Pointer(namespace, tc.lwCache.indexer, tc.kc)
+{{end}}
+{{- if .Indexers}}
+// ByIndex returns the cached objects of which the indexName index has value, without fallback
+func (tc *{{.Plural}}Cache) ByIndex(indexName, value string) []*{{.ItemType}} {
+	items := tc.lwCache.byIndex(indexName, value)
+	re := make([]*{{.ItemType}}, 0, len(items))
+	for _, obj := range items {
+		if {{.VarName}} := as{{.Name}}(obj); {{.VarName}} != nil {
+			re = append(re, {{.VarName}})
+		}
+	}
+	return re
 }
 {{end}}
 func (tc *{{.Plural}}Cache) Indexes() cache.Indexer {
@@ -150,69 +352,110 @@ func Get{{.Name}}CacheConfig(kc kubernetes.Interface) (cache.ListerWatcher, runt
 	return &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 			options.FieldSelector = fields.Everything().String()
-			return {{if .IsNonNamespaced}}kc.{{.ClientPkgName}}().{{.ClientName}}().List(options){{else}}kc.{{.ClientPkgName}}().{{.ClientName}}(metav1.NamespaceAll).List(options){{end}}
+			return {{.Client "metav1.NamespaceAll"}}.List(options)
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
 			options.FieldSelector = fields.Everything().String()
 			options.Watch = true
-			return {{if .IsNonNamespaced}}kc.{{.ClientPkgName}}().{{.ClientName}}().Watch(options){{else}}kc.{{.ClientPkgName}}().{{.ClientName}}(metav1.NamespaceAll).Watch(options){{end}}
+			return {{.Client "metav1.NamespaceAll"}}.Watch(options)
 		},
-	}, &{{.ImportName}}.{{.Name}}{}
+	}, &{{.SourceType}}{}
 }
 
-{{if .IsNonNamespaced}}func CacheGet{{.Name}}(key string, indexer cache.Indexer, kc kubernetes.Interface) (*{{.ImportName}}.{{.Name}}, error) {
-{{else}}func CacheGet{{.Name}}(namespace, key string, indexer cache.Indexer, kc kubernetes.Interface) (*{{.ImportName}}.{{.Name}}, error) {
-{{end}}	if indexer != nil {
-		if obj, exist, e := indexer.GetByKey(key); exist && obj != nil && e == nil {
-			{{if .IsNonNamespaced}}if {{.VarName}}, _ := obj.(*{{.ImportName}}.{{.Name}}); {{.VarName}} != nil && {{.VarName}}.Name == key {
-			{{else}}if {{.VarName}}, _ := obj.(*{{.ImportName}}.{{.Name}}); CheckNamespace({{.VarName}}, namespace) && {{.VarName}}.Name == key {
-			{{end}}	return {{.VarName}}, nil
-			}
+func as{{.Name}}(obj interface{}) *{{.ItemType}} {
+{{- if .Projection}}
+	return To{{.Name}}Projection(obj)
+{{- else}}
+	{{.VarName}}, _ := obj.(*{{.SourceType}})
+	return {{.VarName}}
+{{- end}}
+}
+{{if .Namespaced}}
+func CacheGet{{.Name}}(ctx context.Context, namespace, key string, c *ListWatchCache, kc kubernetes.Interface) (*{{.ItemType}}, error) {
+	if obj, exist, e := c.indexer.GetByKey(NamespaceKey(namespace, key)); exist && obj != nil && e == nil {
+		if {{.VarName}} := as{{.Name}}(obj); {{.VarName}} != nil && CheckNamespace({{.VarName}}, namespace) && {{.VarName}}.Name == key {
+			return {{.VarName}}, nil
 		}
 	}
+{{else}}
+func CacheGet{{.Name}}(ctx context.Context, key string, c *ListWatchCache, kc kubernetes.Interface) (*{{.ItemType}}, error) {
+	if obj, exist, e := c.indexer.GetByKey(key); exist && obj != nil && e == nil {
+		if {{.VarName}} := as{{.Name}}(obj); {{.VarName}} != nil && {{.VarName}}.Name == key {
+			return {{.VarName}}, nil
+		}
+	}
+{{end -}}
 	if kc == nil {
 		return nil, errors.ErrVarKubeClientNil
 	}
-	{{.VarName}}, e := kc.{{.ClientPkgName}}().{{.ClientName}}({{if .IsNonNamespaced}}{{else}}namespace{{end}}).Get(key, metav1.GetOptions{})
+	obj, e := c.fallback.Do(ctx, {{if .Namespaced}}"get/"+NamespaceKey(namespace, key){{else}}"get/"+key{{end}}, func() (interface{}, error) {
+		return {{.Client "namespace"}}.Get(key, metav1.GetOptions{})
+	})
 	if e != nil {
-		return nil, e
+		return nil, FallbackGetError(key, e)
 	}
-	return {{.VarName}}, nil
+{{- if .Projection}}
+	return New{{.Name}}Projection(obj.(*{{.SourceType}})), nil
+{{- else}}
+	return obj.(*{{.SourceType}}), nil
+{{- end}}
 }
-
-{{if .IsNonNamespaced}}func CacheList{{.Plural}}(indexer cache.Indexer, kc kubernetes.Interface) ([]{{.ImportName}}.{{.Name}}, error) {
-{{else}}func CacheList{{.Plural}}(namespace string, indexer cache.Indexer, kc kubernetes.Interface) ([]{{.ImportName}}.{{.Name}}, error) {
-{{end}}	if items := indexer.List(); len(items) > 0 {
-		re := make([]{{.ImportName}}.{{.Name}}, 0, len(items))
+{{if .Namespaced}}
+func CacheList{{.Plural}}(ctx context.Context, namespace string, c *ListWatchCache, kc kubernetes.Interface) ([]{{.ItemType}}, error) {
+	if items := c.listInNamespace(namespace); len(items) > 0 {
+{{- else}}
+func CacheList{{.Plural}}(ctx context.Context, c *ListWatchCache, kc kubernetes.Interface) ([]{{.ItemType}}, error) {
+	if items := c.indexer.List(); len(items) > 0 {
+{{- end}}
+		re := make([]{{.ItemType}}, 0, len(items))
 		for _, obj := range items {
-			{{.VarName}}, _ := obj.(*{{.ImportName}}.{{.Name}})
-			{{if .IsNonNamespaced}}if {{.VarName}} != nil {
-			{{else}}if CheckNamespace({{.VarName}}, namespace) {
-			{{end}}	re = append(re, *{{.VarName}})
+			{{.VarName}} := as{{.Name}}(obj)
+			if {{.VarName}} != nil{{if .Namespaced}} && CheckNamespace({{.VarName}}, namespace){{end}} {
+				re = append(re, *{{.VarName}})
 			}
 		}
 		if len(re) > 0 {
 			return re, nil
 		}
 	}
-	{{.VarName}}List, e := kc.{{.ClientPkgName}}().{{.ClientName}}({{if .IsNonNamespaced}}{{else}}namespace{{end}}).List(metav1.ListOptions{})
+	if !c.fallback.Enabled() {
+		return nil, nil
+	}
+	if kc == nil {
+		return nil, errors.ErrVarKubeClientNil
+	}
+	obj, e := c.fallback.Do(ctx, {{if .Namespaced}}"list/"+namespace{{else}}"list/"{{end}}, func() (interface{}, error) {
+		return {{.Client "namespace"}}.List(metav1.ListOptions{})
+	})
 	if e != nil {
 		return nil, e
 	}
-	return {{.VarName}}List.Items, nil
+{{- if .Projection}}
+	{{.VarName}}List := obj.(*{{.SourceType}}List)
+	re := make([]{{.ItemType}}, len({{.VarName}}List.Items))
+	for i := range {{.VarName}}List.Items {
+		re[i] = *New{{.Name}}Projection(&{{.VarName}}List.Items[i])
+	}
+	return re, nil
+{{- else}}
+	return obj.(*{{.SourceType}}List).Items, nil
+{{- end}}
 }
-
-{{if .IsNonNamespaced}}func CacheList{{.Plural}}Pointer(indexer cache.Indexer, kc kubernetes.Interface) (re []*{{.ImportName}}.{{.Name}}) {
-{{else}}func CacheList{{.Plural}}Pointer(namespace string, indexer cache.Indexer, kc kubernetes.Interface) (re []*{{.ImportName}}.{{.Name}}) {
-{{end}}	// from cache
-	items := indexer.List()
+{{if .Namespaced}}
+func CacheList{{.Plural}}Pointer(ctx context.Context, namespace string, c *ListWatchCache, kc kubernetes.Interface) (re []*{{.ItemType}}) {
+	// from cache
+	items := c.listInNamespace(namespace)
+{{- else}}
+func CacheList{{.Plural}}Pointer(ctx context.Context, c *ListWatchCache, kc kubernetes.Interface) (re []*{{.ItemType}}) {
+	// from cache
+	items := c.indexer.List()
+{{- end}}
 	if len(items) > 0 {
-		re = make([]*{{.ImportName}}.{{.Name}}, 0, len(items))
+		re = make([]*{{.ItemType}}, 0, len(items))
 		for _, obj := range items {
-			{{.VarName}}, _ := obj.(*{{.ImportName}}.{{.Name}})
-			{{if .IsNonNamespaced}}if {{.VarName}} != nil {
-			{{else}}if CheckNamespace({{.VarName}}, namespace) {
-			{{end}}	re = append(re, {{.VarName}})
+			{{.VarName}} := as{{.Name}}(obj)
+			if {{.VarName}} != nil{{if .Namespaced}} && CheckNamespace({{.VarName}}, namespace){{end}} {
+				re = append(re, {{.VarName}})
 			}
 		}
 	}
@@ -220,14 +463,107 @@ func Get{{.Name}}CacheConfig(kc kubernetes.Interface) (cache.ListerWatcher, runt
 		return re
 	}
 	// from source
-	{{.VarName}}List, e := kc.{{.ClientPkgName}}().{{.ClientName}}({{if .IsNonNamespaced}}{{else}}namespace{{end}}).List(metav1.ListOptions{})
-	if e != nil || len({{.VarName}}List.Items) == 0 {
+	if kc == nil || !c.fallback.Enabled() {
 		return nil
 	}
-	re = make([]*{{.ImportName}}.{{.Name}}, len({{.VarName}}List.Items))
+	obj, e := c.fallback.Do(ctx, {{if .Namespaced}}"list/"+namespace{{else}}"list/"{{end}}, func() (interface{}, error) {
+		return {{.Client "namespace"}}.List(metav1.ListOptions{})
+	})
+	if e != nil {
+		return nil
+	}
+	{{.VarName}}List := obj.(*{{.SourceType}}List)
+	if len({{.VarName}}List.Items) == 0 {
+		return nil
+	}
+	re = make([]*{{.ItemType}}, len({{.VarName}}List.Items))
 	for i := range {{.VarName}}List.Items {
+{{- if .Projection}}
+		re[i] = New{{.Name}}Projection(&{{.VarName}}List.Items[i])
+{{- else}}
 		re[i] = &{{.VarName}}List.Items[i]
+{{- end}}
 	}
 	return re
 }
 `
+
+const RegistryTemplate = header + `package {{.Package}}
+
+// registeredConfigs are all the resource caches a cluster can enable
+var registeredConfigs = []Config{
+{{- range .Resources}}{{if not .ControlCluster}}
+	{{.VarName}}CacheConfig,
+{{- end}}{{end}}
+}
+
+// defaultCacheNames are enabled when no cache set config is given
+var defaultCacheNames = []string{
+{{- range .Resources}}{{if .Default}}
+	CacheName{{.Name}},
+{{- end}}{{end}}
+}
+`
+
+const TestTemplate = header + `package {{.Package}}
+
+import (
+	"context"
+	"testing"
+
+	"github.com/caicloud/dashboard-admin/pkg/kubernetes"
+)
+
+var typedCacheCases = []typedCacheCase{
+{{- range .Resources}}
+	{
+		name:       CacheName{{.Name}},
+		apiPath:    "{{.APIPath}}",
+		resource:   "{{.Resource}}",
+		kind:       "{{.Name}}",
+		namespaced: {{.Namespaced}},
+		registered: {{not .ControlCluster}},
+		isDefault:  {{.Default}},
+		newCache: func(kc kubernetes.Interface) (*ListWatchCache, error) {
+			tc, e := New{{.Plural}}Cache(kc)
+			if e != nil {
+				return nil, e
+			}
+			return tc.lwCache, nil
+		},
+		get: func(ctx context.Context, c *ListWatchCache, kc kubernetes.Interface, namespace, name string) (string, error) {
+			{{.VarName}}, e := CacheGet{{.Name}}(ctx, {{if .Namespaced}}namespace, {{end}}name, c, kc)
+			if e != nil {
+				return "", e
+			}
+			return {{.VarName}}.Name, nil
+		},
+		list: func(ctx context.Context, c *ListWatchCache, kc kubernetes.Interface, namespace string) (int, error) {
+			items, e := CacheList{{.Plural}}(ctx, {{if .Namespaced}}namespace, {{end}}c, kc)
+			return len(items), e
+		},
+		listPointer: func(ctx context.Context, c *ListWatchCache, kc kubernetes.Interface, namespace string) int {
+			return len(CacheList{{.Plural}}Pointer(ctx, {{if .Namespaced}}namespace, {{end}}c, kc))
+		},
+	},
+{{- end}}
+}
+
+func TestTypedCacheFallback(t *testing.T) {
+	for _, tc := range typedCacheCases {
+		t.Run(tc.name, tc.testFallback)
+	}
+}
+
+func TestTypedCacheSynced(t *testing.T) {
+	for _, tc := range typedCacheCases {
+		t.Run(tc.name, tc.testSynced)
+	}
+}
+
+func TestTypedCacheRegistry(t *testing.T) {
+	for _, tc := range typedCacheCases {
+		t.Run(tc.name, tc.testRegistry)
+	}
+}
+`
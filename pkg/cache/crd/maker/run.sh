@@ -1,8 +1,3 @@
 #!/usr/bin/env bash
-for input in `ls *.json`
-do
-	name=`echo ${input} | awk -F '.' '{print $1}'`
-	output="../${name}.go"
-	echo ${input} to ${output}
-	go run maker.go -c ${input} -o ${output}
-done
\ No newline at end of file
+# generate the typed caches of ../ from manifest.json, pass -check to only check them
+cd "$(dirname "$0")" && go run maker.go -m manifest.json -o .. "$@"
@@ -0,0 +1,98 @@
+package crd
+
+import (
+	rlsv1a1 "github.com/caicloud/clientset/pkg/apis/release/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/caicloud/dashboard-admin/pkg/cache/notify"
+)
+
+// cacheTopics are the topics changed by the objects of the caches, the
+// events one only by the changes making app events
+var cacheTopics = map[string][]notify.Topic{
+	CacheNameNode:    {notify.TopicClusters},
+	CacheNamePod:     {notify.TopicClusters},
+	CacheNameRelease: {notify.TopicApps, notify.TopicClusters, notify.TopicEvents},
+}
+
+// clusterTopics are the topics of whole clusters, the only ones changed by the
+// objects in namespaces of no tenant, or whose partition is not synced yet,
+// which must not reach the subscribers of every tenant
+var clusterTopics = map[notify.Topic]bool{
+	notify.TopicClusters: true,
+}
+
+// SetNotifier must be called before Run
+func (rc *ClusterResourcesCache) SetNotifier(n notify.Notifier) {
+	rc.notifier = n
+}
+
+func (rc *ClusterResourcesCache) notify(change notify.Change) {
+	if rc.notifier != nil {
+		rc.notifier.Notify(change)
+	}
+}
+
+// eventHandler returns the handler of cache name, which updates the counters,
+// records the app events and tells the notifier, nil if none is needed
+func (scc *subClusterCaches) eventHandler(name string) cache.ResourceEventHandler {
+	counter := scc.counters.EventHandler(name)
+	topics := cacheTopics[name]
+	if counter == nil && len(topics) == 0 {
+		return nil
+	}
+	if counter == nil {
+		counter = cache.ResourceEventHandlerFuncs{}
+	}
+	changed := func(eventType string, oldObj, obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		namespaced, tenant := false, ""
+		if accessor, e := meta.Accessor(obj); e == nil && len(accessor.GetNamespace()) > 0 {
+			namespaced, tenant = true, scc.tenantOfNamespace(accessor.GetNamespace())
+		}
+		var ev *AppEvent
+		if name == CacheNameRelease && scc.events != nil && scc.cacheSynced(name) {
+			old, _ := oldObj.(*rlsv1a1.Release)
+			if ev = newAppEvent(eventType, old, asRelease(obj)); ev != nil {
+				ev.Tenant, ev.Cluster = tenant, scc.name
+				scc.events.add(*ev)
+			}
+		}
+		if scc.notifier == nil {
+			return
+		}
+		for _, topic := range topics {
+			if topic == notify.TopicEvents && ev == nil {
+				continue
+			}
+			if namespaced && len(tenant) == 0 && !clusterTopics[topic] {
+				continue
+			}
+			scc.notifier.Notify(notify.Change{Topic: topic, Tenant: tenant, Cluster: scc.name})
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			counter.OnAdd(obj)
+			changed(AppEventCreate, nil, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			counter.OnUpdate(oldObj, newObj)
+			changed(AppEventUpdate, oldObj, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			counter.OnDelete(obj)
+			changed(AppEventDelete, nil, obj)
+		},
+	}
+}
+
+// cacheSynced checks if cache name has synced, the objects listed before are
+// not changes worth an event
+func (scc *subClusterCaches) cacheSynced(name string) bool {
+	c, ok := scc.GetCoreCache(name)
+	return ok && c.HasSynced()
+}
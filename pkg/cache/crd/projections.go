@@ -0,0 +1,314 @@
+package crd
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Projections are the compact forms of the objects kept in the caches,
+// only the fields used by dashboard are copied from the source objects.
+
+var (
+	PodTransformer = &Transformer{
+		ObjType:   &PodProjection{},
+		Transform: TransformPod,
+	}
+	NodeTransformer = &Transformer{
+		ObjType:   &NodeProjection{},
+		Transform: TransformNode,
+	}
+)
+
+// pod
+
+type PodProjection struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Phase          corev1.PodPhase       `json:"phase"`
+	NodeName       string                `json:"nodeName,omitempty"`
+	Containers     []ContainerProjection `json:"containers"`
+	InitContainers []ContainerProjection `json:"initContainers,omitempty"`
+}
+
+type ContainerProjection struct {
+	Name         string                      `json:"name"`
+	Resources    corev1.ResourceRequirements `json:"resources"`
+	RestartCount int32                       `json:"restartCount"`
+}
+
+func NewPodProjection(pod *corev1.Pod) *PodProjection {
+	if pod == nil {
+		return nil
+	}
+	p := &PodProjection{
+		ObjectMeta:     projectObjectMeta(&pod.ObjectMeta),
+		Phase:          pod.Status.Phase,
+		NodeName:       pod.Spec.NodeName,
+		Containers:     projectContainers(pod.Spec.Containers, pod.Status.ContainerStatuses),
+		InitContainers: projectContainers(pod.Spec.InitContainers, pod.Status.InitContainerStatuses),
+	}
+	return p
+}
+
+func TransformPod(obj runtime.Object) (runtime.Object, error) {
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		return NewPodProjection(o), nil
+	case *PodProjection:
+		return o, nil
+	}
+	return nil, fmt.Errorf("unexpected type %T for pod projection", obj)
+}
+
+// ToPodProjection accepts objects stored with or without PodTransformer
+func ToPodProjection(obj interface{}) *PodProjection {
+	switch o := obj.(type) {
+	case *PodProjection:
+		return o
+	case *corev1.Pod:
+		return NewPodProjection(o)
+	}
+	return nil
+}
+
+func (p *PodProjection) RestartCount() (n int32) {
+	for i := range p.Containers {
+		n += p.Containers[i].RestartCount
+	}
+	return n
+}
+
+// IsTerminated pods do not hold resources any more
+func (p *PodProjection) IsTerminated() bool {
+	return p.Phase == corev1.PodSucceeded || p.Phase == corev1.PodFailed
+}
+
+// ResourceRequests is the effective requests of the pod, the larger one of
+// the sum of containers and the max of init containers for each resource
+func (p *PodProjection) ResourceRequests() corev1.ResourceList {
+	return p.effectiveResources(func(r *corev1.ResourceRequirements) corev1.ResourceList { return r.Requests })
+}
+
+func (p *PodProjection) ResourceLimits() corev1.ResourceList {
+	return p.effectiveResources(func(r *corev1.ResourceRequirements) corev1.ResourceList { return r.Limits })
+}
+
+func (p *PodProjection) effectiveResources(get func(r *corev1.ResourceRequirements) corev1.ResourceList) corev1.ResourceList {
+	re := corev1.ResourceList{}
+	for i := range p.Containers {
+		AddResourceList(re, get(&p.Containers[i].Resources))
+	}
+	for i := range p.InitContainers {
+		for name, q := range get(&p.InitContainers[i].Resources) {
+			if cur, ok := re[name]; !ok || q.Cmp(cur) > 0 {
+				re[name] = q.DeepCopy()
+			}
+		}
+	}
+	return re
+}
+
+func (p *PodProjection) DeepCopyInto(out *PodProjection) {
+	*out = *p
+	p.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Containers = deepCopyContainers(p.Containers)
+	out.InitContainers = deepCopyContainers(p.InitContainers)
+}
+
+func (p *PodProjection) DeepCopy() *PodProjection {
+	if p == nil {
+		return nil
+	}
+	out := new(PodProjection)
+	p.DeepCopyInto(out)
+	return out
+}
+
+func (p *PodProjection) DeepCopyObject() runtime.Object {
+	if c := p.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// node
+
+type NodeProjection struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Unschedulable bool                   `json:"unschedulable,omitempty"`
+	Taints        []corev1.Taint         `json:"taints,omitempty"`
+	Capacity      corev1.ResourceList    `json:"capacity,omitempty"`
+	Allocatable   corev1.ResourceList    `json:"allocatable,omitempty"`
+	Conditions    []corev1.NodeCondition `json:"conditions,omitempty"`
+	Addresses     []corev1.NodeAddress   `json:"addresses,omitempty"`
+}
+
+func NewNodeProjection(node *corev1.Node) *NodeProjection {
+	if node == nil {
+		return nil
+	}
+	p := &NodeProjection{
+		ObjectMeta:    projectObjectMeta(&node.ObjectMeta),
+		Unschedulable: node.Spec.Unschedulable,
+		Capacity:      node.Status.Capacity.DeepCopy(),
+		Allocatable:   node.Status.Allocatable.DeepCopy(),
+	}
+	if len(node.Spec.Taints) > 0 {
+		p.Taints = make([]corev1.Taint, len(node.Spec.Taints))
+		for i := range node.Spec.Taints {
+			node.Spec.Taints[i].DeepCopyInto(&p.Taints[i])
+		}
+	}
+	if len(node.Status.Conditions) > 0 {
+		p.Conditions = make([]corev1.NodeCondition, len(node.Status.Conditions))
+		for i := range node.Status.Conditions {
+			node.Status.Conditions[i].DeepCopyInto(&p.Conditions[i])
+		}
+	}
+	if len(node.Status.Addresses) > 0 {
+		p.Addresses = make([]corev1.NodeAddress, len(node.Status.Addresses))
+		copy(p.Addresses, node.Status.Addresses)
+	}
+	return p
+}
+
+func TransformNode(obj runtime.Object) (runtime.Object, error) {
+	switch o := obj.(type) {
+	case *corev1.Node:
+		return NewNodeProjection(o), nil
+	case *NodeProjection:
+		return o, nil
+	}
+	return nil, fmt.Errorf("unexpected type %T for node projection", obj)
+}
+
+// ToNodeProjection accepts objects stored with or without NodeTransformer
+func ToNodeProjection(obj interface{}) *NodeProjection {
+	switch o := obj.(type) {
+	case *NodeProjection:
+		return o
+	case *corev1.Node:
+		return NewNodeProjection(o)
+	}
+	return nil
+}
+
+func (p *NodeProjection) IsReady() bool {
+	for i := range p.Conditions {
+		if p.Conditions[i].Type == corev1.NodeReady {
+			return p.Conditions[i].Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func (p *NodeProjection) DeepCopyInto(out *NodeProjection) {
+	*out = *p
+	p.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if p.Taints != nil {
+		out.Taints = make([]corev1.Taint, len(p.Taints))
+		for i := range p.Taints {
+			p.Taints[i].DeepCopyInto(&out.Taints[i])
+		}
+	}
+	out.Capacity = p.Capacity.DeepCopy()
+	out.Allocatable = p.Allocatable.DeepCopy()
+	if p.Conditions != nil {
+		out.Conditions = make([]corev1.NodeCondition, len(p.Conditions))
+		for i := range p.Conditions {
+			p.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+	if p.Addresses != nil {
+		out.Addresses = make([]corev1.NodeAddress, len(p.Addresses))
+		copy(out.Addresses, p.Addresses)
+	}
+}
+
+func (p *NodeProjection) DeepCopy() *NodeProjection {
+	if p == nil {
+		return nil
+	}
+	out := new(NodeProjection)
+	p.DeepCopyInto(out)
+	return out
+}
+
+func (p *NodeProjection) DeepCopyObject() runtime.Object {
+	if c := p.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// common
+
+func projectObjectMeta(om *metav1.ObjectMeta) metav1.ObjectMeta {
+	re := metav1.ObjectMeta{
+		Name:              om.Name,
+		Namespace:         om.Namespace,
+		UID:               om.UID,
+		ResourceVersion:   om.ResourceVersion,
+		CreationTimestamp: om.CreationTimestamp,
+	}
+	if om.DeletionTimestamp != nil {
+		t := *om.DeletionTimestamp
+		re.DeletionTimestamp = &t
+	}
+	if len(om.Labels) > 0 {
+		re.Labels = make(map[string]string, len(om.Labels))
+		for k, v := range om.Labels {
+			re.Labels[k] = v
+		}
+	}
+	return re
+}
+
+func projectContainers(containers []corev1.Container, statuses []corev1.ContainerStatus) []ContainerProjection {
+	if len(containers) == 0 {
+		return nil
+	}
+	re := make([]ContainerProjection, len(containers))
+	for i := range containers {
+		re[i].Name = containers[i].Name
+		containers[i].Resources.DeepCopyInto(&re[i].Resources)
+		for j := range statuses {
+			if statuses[j].Name == containers[i].Name {
+				re[i].RestartCount = statuses[j].RestartCount
+				break
+			}
+		}
+	}
+	return re
+}
+
+func deepCopyContainers(in []ContainerProjection) []ContainerProjection {
+	if in == nil {
+		return nil
+	}
+	out := make([]ContainerProjection, len(in))
+	for i := range in {
+		out[i].Name = in[i].Name
+		out[i].RestartCount = in[i].RestartCount
+		in[i].Resources.DeepCopyInto(&out[i].Resources)
+	}
+	return out
+}
+
+// AddResourceList adds every quantity of delta to dst
+func AddResourceList(dst, delta corev1.ResourceList) {
+	for name, q := range delta {
+		if cur, ok := dst[name]; ok {
+			cur.Add(q)
+			dst[name] = cur
+		} else {
+			dst[name] = q.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package crd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   testNamespace,
+			Name:        name,
+			Labels:      map[string]string{"app": name},
+			Annotations: map[string]string{"big": "dropped"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Containers: []corev1.Container{{
+				Name: "c",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "c", RestartCount: 3}},
+		},
+	}
+}
+
+func TestToPodProjection(t *testing.T) {
+	pod := newTestPod("a")
+	p := ToPodProjection(pod)
+	if p == nil || p.Name != "a" || p.Namespace != testNamespace || p.Labels["app"] != "a" {
+		t.Fatalf("unexpected projection meta %+v", p)
+	}
+	if p.Phase != corev1.PodRunning || p.NodeName != "node-1" || p.RestartCount() != 3 {
+		t.Fatalf("unexpected projection status %+v", p)
+	}
+	if q := p.ResourceRequests()[corev1.ResourceCPU]; q.MilliValue() != 100 {
+		t.Fatalf("unexpected requests %v", p.ResourceRequests())
+	}
+	if q := p.ResourceLimits()[corev1.ResourceCPU]; q.MilliValue() != 200 {
+		t.Fatalf("unexpected limits %v", p.ResourceLimits())
+	}
+	// the source is not shared
+	pod.Labels["app"] = "changed"
+	if p.Labels["app"] != "a" {
+		t.Fatalf("projection shares labels with the pod")
+	}
+	if ToPodProjection(p) != p {
+		t.Fatalf("projection should be returned as is")
+	}
+	if ToPodProjection(&corev1.Node{}) != nil {
+		t.Fatalf("node should not be a pod projection")
+	}
+}
+
+func TestToNodeProjection(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec: corev1.NodeSpec{
+			Unschedulable: true,
+			Taints:        []corev1.Taint{{Key: "k", Effect: corev1.TaintEffectNoSchedule}},
+		},
+		Status: corev1.NodeStatus{
+			Capacity:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	p := ToNodeProjection(node)
+	if p == nil || p.Name != "node-1" || !p.Unschedulable || !p.IsReady() {
+		t.Fatalf("unexpected projection %+v", p)
+	}
+	if q := p.Capacity[corev1.ResourceCPU]; q.Value() != 4 {
+		t.Fatalf("unexpected capacity %v", p.Capacity)
+	}
+	node.Spec.Taints[0].Key = "changed"
+	node.Status.Conditions[0].Status = corev1.ConditionFalse
+	if p.Taints[0].Key != "k" || !p.IsReady() {
+		t.Fatalf("projection shares taints or conditions with the node")
+	}
+	if ToNodeProjection(p) != p {
+		t.Fatalf("projection should be returned as is")
+	}
+	if ToNodeProjection(newTestPod("a")) != nil {
+		t.Fatalf("pod should not be a node projection")
+	}
+}
+
+func TestTransformListWatcherList(t *testing.T) {
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return &corev1.PodList{
+				ListMeta: metav1.ListMeta{ResourceVersion: "7"},
+				Items:    []corev1.Pod{*newTestPod("a"), *newTestPod("b")},
+			}, nil
+		},
+	}
+	obj, e := (&transformListWatcher{lw: lw, transformer: PodTransformer}).List(metav1.ListOptions{})
+	if e != nil {
+		t.Fatalf("list failed, %v", e)
+	}
+	list, _ := obj.(*metav1.List)
+	if list == nil || list.ResourceVersion != "7" || len(list.Items) != 2 {
+		t.Fatalf("unexpected list %+v", obj)
+	}
+	for i, name := range []string{"a", "b"} {
+		if p, _ := list.Items[i].Object.(*PodProjection); p == nil || p.Name != name {
+			t.Fatalf("unexpected item %d %+v", i, list.Items[i].Object)
+		}
+	}
+	// a list of other objects fails as a whole
+	if _, e = (&transformListWatcher{lw: lw, transformer: NodeTransformer}).List(metav1.ListOptions{}); e == nil {
+		t.Fatalf("list of pods should fail to transform to nodes")
+	}
+}
+
+func TestTransformListWatcherWatch(t *testing.T) {
+	fw := watch.NewFake()
+	lw := &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return fw, nil
+		},
+	}
+	w, e := (&transformListWatcher{lw: lw, transformer: PodTransformer}).Watch(metav1.ListOptions{})
+	if e != nil {
+		t.Fatalf("watch failed, %v", e)
+	}
+	defer w.Stop()
+	go func() {
+		fw.Add(newTestPod("a"))
+		// dropped as it fails to transform
+		fw.Add(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+		fw.Error(&metav1.Status{Message: "gone"})
+		fw.Modify(newTestPod("b"))
+	}()
+	next := func() watch.Event {
+		select {
+		case ev := <-w.ResultChan():
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for event")
+		}
+		return watch.Event{}
+	}
+	for _, want := range []string{"ADDED/a", "ERROR/", "MODIFIED/b"} {
+		ev := next()
+		name := ""
+		if p, ok := ev.Object.(*PodProjection); ok {
+			name = p.Name
+		} else if ev.Type != watch.Error {
+			t.Fatalf("unexpected event object %T", ev.Object)
+		}
+		if got := fmt.Sprintf("%s/%s", ev.Type, name); got != want {
+			t.Fatalf("expect event %s, got %s", want, got)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package crd
+
+import (
+	"context"
+	"time"
+
+	resv1b1 "github.com/caicloud/clientset/pkg/apis/resource/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/caicloud/dashboard-admin/pkg/errors"
+)
+
+// ClusterSummary is the summary level projection of the caches of a cluster,
+// small enough to be kept in snapshots.
+type ClusterSummary struct {
+	Name  string               `json:"name"`
+	Phase resv1b1.ClusterPhase `json:"phase"`
+
+	NodeNum      int                     `json:"nodeNum"`
+	ReadyNodeNum int                     `json:"readyNodeNum"`
+	PodNum       int                     `json:"podNum"`
+	PodPhases    map[corev1.PodPhase]int `json:"podPhases,omitempty"`
+	ReleaseNum   int                     `json:"releaseNum"`
+
+	ReleaseStates map[ReleaseState]int `json:"releaseStates,omitempty"`
+
+	Capacity    corev1.ResourceList `json:"capacity,omitempty"`
+	Allocatable corev1.ResourceList `json:"allocatable,omitempty"`
+	// of the pods not terminated
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	Limits   corev1.ResourceList `json:"limits,omitempty"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+	// the summary is restored from snapshot, or has only name and phase, as
+	// caches of the cluster are not synced yet
+	Stale bool `json:"stale"`
+}
+
+func (cs *ClusterSummary) DeepCopy() *ClusterSummary {
+	if cs == nil {
+		return nil
+	}
+	out := *cs
+	if cs.PodPhases != nil {
+		out.PodPhases = make(map[corev1.PodPhase]int, len(cs.PodPhases))
+		for k, v := range cs.PodPhases {
+			out.PodPhases[k] = v
+		}
+	}
+	if cs.ReleaseStates != nil {
+		out.ReleaseStates = make(map[ReleaseState]int, len(cs.ReleaseStates))
+		for k, v := range cs.ReleaseStates {
+			out.ReleaseStates[k] = v
+		}
+	}
+	out.Capacity = cs.Capacity.DeepCopy()
+	out.Allocatable = cs.Allocatable.DeepCopy()
+	out.Requests = cs.Requests.DeepCopy()
+	out.Limits = cs.Limits.DeepCopy()
+	return &out
+}
+
+// Summary reads the counters of the enabled caches, disabled ones are counted as empty
+func (scc *subClusterCaches) Summary(cluster *resv1b1.Cluster) *ClusterSummary {
+	cs := newClusterSummary(scc.counters.Cluster())
+	cs.Name = cluster.Name
+	cs.Phase = cluster.Status.Phase
+	return cs
+}
+
+// TenantSummaries returns the summaries of the tenants in the cluster by
+// tenant name, nodes are not counted for tenants
+func (scc *subClusterCaches) TenantSummaries(cluster *resv1b1.Cluster) map[string]*ClusterSummary {
+	counts := scc.counters.Tenants()
+	re := make(map[string]*ClusterSummary, len(counts))
+	for tenant, c := range counts {
+		cs := newClusterSummary(c)
+		cs.Name = cluster.Name
+		cs.Phase = cluster.Status.Phase
+		re[tenant] = cs
+	}
+	return re
+}
+
+func newClusterSummary(c *Counts) *ClusterSummary {
+	return &ClusterSummary{
+		NodeNum:       c.NodeNum,
+		ReadyNodeNum:  c.ReadyNodeNum,
+		PodNum:        c.PodNum,
+		PodPhases:     c.PodPhases,
+		ReleaseNum:    c.ReleaseNum,
+		ReleaseStates: c.ReleaseStates,
+		Capacity:      c.Capacity,
+		Allocatable:   c.Allocatable,
+		Requests:      c.Requests,
+		Limits:        c.Limits,
+		UpdatedAt:     time.Now(),
+	}
+}
+
+// GetClusterSummary returns the live summary of the cluster if its caches
+// are synced, or the stale one restored from snapshot.
+func (rc *ClusterResourcesCache) GetClusterSummary(ctx context.Context, clusterName string) (*ClusterSummary, *errors.FormatError) {
+	item, _, _ := rc.cc.indexer.GetByKey(clusterName)
+	cluster, _ := item.(*resv1b1.Cluster)
+	if cluster == nil && rc.cc.HasSynced() {
+		return nil, errors.NewError().SetErrorObjectNotFound(clusterName, nil)
+	}
+
+	rc.mLock.RLock()
+	c := rc.m[clusterName]
+	rc.mLock.RUnlock()
+	if c != nil && cluster != nil && c.HasSynced() {
+		c.touch()
+		return c.Summary(cluster), nil
+	}
+	if cs := rc.getSnapshotSummary(clusterName); cs != nil {
+		return cs, nil
+	}
+	if cluster == nil {
+		return nil, errors.NewError().SetErrorObjectNotFound(clusterName, nil)
+	}
+	c, fe := rc.GetSubClusterCaches(ctx, clusterName)
+	if fe != nil {
+		return nil, fe
+	}
+	return c.Summary(cluster), nil
+}
+
+// ListClusterSummaries returns the summaries of all the clusters, clusters
+// not ready have only name and phase. Before the cluster cache is synced,
+// the clusters in snapshot are returned. It never starts or touches the
+// caches of the clusters.
+func (rc *ClusterResourcesCache) ListClusterSummaries(ctx context.Context) []ClusterSummary {
+	if !rc.cc.HasSynced() {
+		return rc.listSnapshotSummaries()
+	}
+	items := rc.cc.List()
+	re := make([]ClusterSummary, 0, len(items))
+	for _, obj := range items {
+		cluster, _ := obj.(*resv1b1.Cluster)
+		if cluster == nil {
+			continue
+		}
+		re = append(re, *rc.peekClusterSummary(cluster))
+	}
+	return re
+}
+
+// peekClusterSummary returns the live summary of the cluster if its caches
+// are running and synced, or the one restored from snapshot, or the stale
+// one of name and phase only if the cluster is cacheable
+func (rc *ClusterResourcesCache) peekClusterSummary(cluster *resv1b1.Cluster) *ClusterSummary {
+	rc.mLock.RLock()
+	c := rc.m[cluster.Name]
+	rc.mLock.RUnlock()
+	if c != nil && c.HasSynced() {
+		return c.Summary(cluster)
+	}
+	if cs := rc.getSnapshotSummary(cluster.Name); cs != nil {
+		return cs
+	}
+	return &ClusterSummary{
+		Name:      cluster.Name,
+		Phase:     cluster.Status.Phase,
+		UpdatedAt: time.Now(),
+		Stale:     isClusterCacheable(cluster.Status.Phase),
+	}
+}
+
+// GetTenantSummary returns the live summary of tenant in the cluster, which
+// has no snapshot
+func (rc *ClusterResourcesCache) GetTenantSummary(ctx context.Context, clusterName, tenant string) (*ClusterSummary, *errors.FormatError) {
+	item, _, _ := rc.cc.indexer.GetByKey(clusterName)
+	cluster, _ := item.(*resv1b1.Cluster)
+	if cluster == nil {
+		return nil, errors.NewError().SetErrorObjectNotFound(clusterName, nil)
+	}
+	c, fe := rc.GetSubClusterCaches(ctx, clusterName)
+	if fe != nil {
+		return nil, fe
+	}
+	cs := newClusterSummary(c.counters.Tenant(tenant))
+	cs.Name = cluster.Name
+	cs.Phase = cluster.Status.Phase
+	return cs, nil
+}
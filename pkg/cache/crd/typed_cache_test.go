@@ -0,0 +1,254 @@
+package crd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/caicloud/dashboard-admin/pkg/kubernetes"
+)
+
+// typedCacheCase is filled for every resource of maker/manifest.json in registry_test.go
+type typedCacheCase struct {
+	name       string
+	apiPath    string
+	resource   string
+	kind       string
+	namespaced bool
+	registered bool
+	isDefault  bool
+
+	newCache    func(kc kubernetes.Interface) (*ListWatchCache, error)
+	get         func(ctx context.Context, c *ListWatchCache, kc kubernetes.Interface, namespace, name string) (string, error)
+	list        func(ctx context.Context, c *ListWatchCache, kc kubernetes.Interface, namespace string) (int, error)
+	listPointer func(ctx context.Context, c *ListWatchCache, kc kubernetes.Interface, namespace string) int
+}
+
+const testNamespace = "ns1"
+
+func (tc *typedCacheCase) objects() []fakeObject {
+	ns := ""
+	if tc.namespaced {
+		ns = testNamespace
+	}
+	return []fakeObject{{Namespace: ns, Name: "a"}, {Namespace: ns, Name: "b"}}
+}
+
+func (tc *typedCacheCase) testFallback(t *testing.T) {
+	srv := newFakeAPIServer(tc, tc.objects())
+	defer srv.Close()
+	kc := srv.Client(t)
+	c, e := tc.newCache(kc)
+	if e != nil {
+		t.Fatalf("new cache failed, %v", e)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// not synced, read from source
+	name, e := tc.get(ctx, c, kc, testNamespace, "a")
+	if e != nil || name != "a" {
+		t.Fatalf("get from source got %q, %v", name, e)
+	}
+	if _, e = tc.get(ctx, c, kc, testNamespace, "missing"); e == nil {
+		t.Fatalf("get missing object from source should fail")
+	}
+	if n, e := tc.list(ctx, c, kc, testNamespace); e != nil || n != 2 {
+		t.Fatalf("list from source got %d, %v", n, e)
+	}
+	if n := tc.listPointer(ctx, c, kc, testNamespace); n != 2 {
+		t.Fatalf("list pointer from source got %d", n)
+	}
+
+	// fallback disabled
+	c.SetFallbackConfig(FallbackConfig{Policy: FallbackNever})
+	if _, e = tc.get(ctx, c, kc, testNamespace, "a"); e == nil {
+		t.Fatalf("get without fallback should fail")
+	}
+	if n, e := tc.list(ctx, c, kc, testNamespace); e != nil || n != 0 {
+		t.Fatalf("list without fallback got %d, %v", n, e)
+	}
+	if n := srv.Requests(); n != 4 {
+		t.Fatalf("expect 4 requests to source, got %d", n)
+	}
+}
+
+func (tc *typedCacheCase) testSynced(t *testing.T) {
+	srv := newFakeAPIServer(tc, tc.objects())
+	defer srv.Close()
+	kc := srv.Client(t)
+	c, e := tc.newCache(kc)
+	if e != nil {
+		t.Fatalf("new cache failed, %v", e)
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go c.Run(stopCh)
+	deadline := time.Now().Add(5 * time.Second)
+	for !c.HasSynced() {
+		if time.Now().After(deadline) {
+			t.Fatalf("cache not synced")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.SetFallbackConfig(FallbackConfig{Policy: FallbackNever})
+	ctx := context.Background()
+
+	name, e := tc.get(ctx, c, kc, testNamespace, "b")
+	if e != nil || name != "b" {
+		t.Fatalf("get from cache got %q, %v", name, e)
+	}
+	if n, e := tc.list(ctx, c, kc, testNamespace); e != nil || n != 2 {
+		t.Fatalf("list from cache got %d, %v", n, e)
+	}
+	if n := tc.listPointer(ctx, c, kc, testNamespace); n != 2 {
+		t.Fatalf("list pointer from cache got %d", n)
+	}
+	if tc.namespaced {
+		if n, e := tc.list(ctx, c, kc, "ns2"); e != nil || n != 0 {
+			t.Fatalf("list other namespace got %d, %v", n, e)
+		}
+	}
+}
+
+func (tc *typedCacheCase) testRegistry(t *testing.T) {
+	if IsRegisteredCacheName(tc.name) != tc.registered {
+		t.Fatalf("registered should be %v", tc.registered)
+	}
+	isDefault := false
+	for _, name := range GetDefaultCacheNames() {
+		if name == tc.name {
+			isDefault = true
+		}
+	}
+	if isDefault != tc.isDefault {
+		t.Fatalf("default should be %v", tc.isDefault)
+	}
+	if !tc.registered {
+		return
+	}
+	configs, e := GetConfigByNames([]string{tc.name})
+	if e != nil || len(configs) != 1 || configs[0].Name != tc.name {
+		t.Fatalf("get config got %v, %v", configs, e)
+	}
+}
+
+// fake api server
+
+type fakeObject struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// fakeAPIServer serves list, get and watch of one resource for the real clientset
+type fakeAPIServer struct {
+	*httptest.Server
+	tc       *typedCacheCase
+	objects  []fakeObject
+	stopCh   chan struct{}
+	requests chan struct{}
+}
+
+func newFakeAPIServer(tc *typedCacheCase, objects []fakeObject) *fakeAPIServer {
+	s := &fakeAPIServer{
+		tc:       tc,
+		objects:  objects,
+		stopCh:   make(chan struct{}),
+		requests: make(chan struct{}, 1024),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *fakeAPIServer) Client(t *testing.T) kubernetes.Interface {
+	kc, e := kubernetes.NewClientFromRestConfig(&rest.Config{Host: s.URL})
+	if e != nil {
+		t.Fatalf("new client failed, %v", e)
+	}
+	return kc
+}
+
+// Requests returns the number of list and get requests
+func (s *fakeAPIServer) Requests() int {
+	return len(s.requests)
+}
+
+func (s *fakeAPIServer) Close() {
+	close(s.stopCh)
+	s.Server.Close()
+}
+
+func (s *fakeAPIServer) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("watch") == "true" {
+		// never changes
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-s.stopCh:
+		}
+		return
+	}
+	s.requests <- struct{}{}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, s.tc.apiPath), "/"), "/")
+	namespace := ""
+	if len(parts) > 2 && parts[0] == "namespaces" {
+		namespace, parts = parts[1], parts[2:]
+	}
+	if len(parts) == 0 || parts[0] != s.tc.resource {
+		s.writeStatus(w, http.StatusNotFound, "NotFound")
+		return
+	}
+	var items []map[string]interface{}
+	for _, obj := range s.objects {
+		if len(namespace) > 0 && obj.Namespace != namespace {
+			continue
+		}
+		if len(parts) == 2 && obj.Name != parts[1] {
+			continue
+		}
+		items = append(items, s.object(s.tc.kind, obj))
+	}
+	if len(parts) == 2 {
+		if len(items) == 0 {
+			s.writeStatus(w, http.StatusNotFound, "NotFound")
+			return
+		}
+		json.NewEncoder(w).Encode(items[0])
+		return
+	}
+	list := s.object(s.tc.kind+"List", fakeObject{})
+	list["items"] = items
+	json.NewEncoder(w).Encode(list)
+}
+
+func (s *fakeAPIServer) object(kind string, obj fakeObject) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": strings.TrimPrefix(strings.TrimPrefix(s.tc.apiPath, "/apis/"), "/api/"),
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":            obj.Name,
+			"namespace":       obj.Namespace,
+			"resourceVersion": "1",
+		},
+	}
+}
+
+func (s *fakeAPIServer) writeStatus(w http.ResponseWriter, code int, reason string) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Status",
+		"status":     "Failure",
+		"reason":     reason,
+		"code":       code,
+	})
+}
@@ -0,0 +1,233 @@
+package notify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+)
+
+// Topic is a dashboard view pushed to watchers when the data behind it changes
+type Topic string
+
+const (
+	TopicClusters Topic = "clusters"
+	TopicApps     Topic = "apps"
+	TopicEvents   Topic = "events"
+	TopicCI       Topic = "ci"
+)
+
+// historySize is the number of flushed batches kept for resuming
+const historySize = 256
+
+// Change of a topic, empty Tenant or Cluster means all of them, so the changes
+// of tenant data must carry their tenant
+type Change struct {
+	Topic   Topic
+	Tenant  string
+	Cluster string
+}
+
+// Notifier is told by the caches when their data changes, it must not block
+type Notifier interface {
+	Notify(change Change)
+}
+
+// Filter chooses the changes a subscription cares about
+type Filter struct {
+	Tenant  string
+	Cluster string
+	Topics  []Topic
+}
+
+// Match checks the change against filter, the system tenant sees all tenants
+func (f *Filter) Match(change Change) bool {
+	if len(change.Tenant) > 0 && f.Tenant != tntv1al.SystemTenant && change.Tenant != f.Tenant {
+		return false
+	}
+	if len(change.Cluster) > 0 && len(f.Cluster) > 0 && change.Cluster != f.Cluster {
+		return false
+	}
+	for _, topic := range f.Topics {
+		if topic == change.Topic {
+			return true
+		}
+	}
+	return false
+}
+
+type batch struct {
+	seq     uint64
+	changes []Change
+}
+
+// Broker collects the changes and flushes them to the subscriptions at most
+// once per debounce. Every flush has a sequence number, event ids are made of
+// the epoch of the broker and the sequence, so a resumed subscription gets the
+// topics changed since its last event id, or all topics if the id is too old
+// or from another broker.
+type Broker struct {
+	debounce time.Duration
+	epoch    string
+
+	lock    sync.Mutex
+	seq     uint64
+	pending map[Change]struct{}
+	timer   *time.Timer
+	history []batch
+	subs    map[*Subscription]struct{}
+}
+
+func NewBroker(debounce time.Duration) *Broker {
+	return &Broker{
+		debounce: debounce,
+		epoch:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		pending:  make(map[Change]struct{}),
+		subs:     make(map[*Subscription]struct{}),
+	}
+}
+
+func (b *Broker) Notify(change Change) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.pending[change] = struct{}{}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.debounce, b.flush)
+	}
+}
+
+func (b *Broker) flush() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.timer = nil
+	if len(b.pending) == 0 {
+		return
+	}
+	b.seq++
+	bt := batch{seq: b.seq, changes: make([]Change, 0, len(b.pending))}
+	for change := range b.pending {
+		bt.changes = append(bt.changes, change)
+	}
+	b.pending = make(map[Change]struct{})
+	b.history = append(b.history, bt)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+	for sub := range b.subs {
+		sub.add(b.seq, bt.changes)
+	}
+}
+
+func (b *Broker) eventID(seq uint64) string {
+	return fmt.Sprintf("%s-%d", b.epoch, seq)
+}
+
+// parseEventID returns the sequence of id, ok is false if id is not from b
+func (b *Broker) parseEventID(id string) (seq uint64, ok bool) {
+	parts := strings.SplitN(id, "-", 2)
+	if len(parts) != 2 || parts[0] != b.epoch {
+		return 0, false
+	}
+	seq, e := strconv.ParseUint(parts[1], 10, 64)
+	return seq, e == nil
+}
+
+// Subscribe starts a subscription from lastEventID, empty means a new one
+// which gets all topics first
+func (b *Broker) Subscribe(filter Filter, lastEventID string) *Subscription {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	sub := &Subscription{
+		b:       b,
+		filter:  filter,
+		seq:     b.seq,
+		pending: make(map[Topic]struct{}),
+		c:       make(chan struct{}, 1),
+	}
+	if lastSeq, ok := b.parseEventID(lastEventID); ok && lastSeq <= b.seq && b.covers(lastSeq) {
+		for _, bt := range b.history {
+			if bt.seq > lastSeq {
+				sub.add(bt.seq, bt.changes)
+			}
+		}
+	} else {
+		for _, topic := range filter.Topics {
+			sub.pending[topic] = struct{}{}
+		}
+		sub.signal()
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// covers checks if the batches after seq are all in history
+func (b *Broker) covers(seq uint64) bool {
+	if seq == b.seq {
+		return true
+	}
+	return len(b.history) > 0 && b.history[0].seq <= seq+1
+}
+
+func (b *Broker) unsubscribe(sub *Subscription) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(b.subs, sub)
+}
+
+// Subscription collects the topics changed for one watcher
+type Subscription struct {
+	b      *Broker
+	filter Filter
+
+	// guarded by b.lock
+	seq     uint64
+	pending map[Topic]struct{}
+	c       chan struct{}
+}
+
+// add must be called with b.lock held
+func (s *Subscription) add(seq uint64, changes []Change) {
+	s.seq = seq
+	matched := false
+	for _, change := range changes {
+		if s.filter.Match(change) {
+			s.pending[change.Topic] = struct{}{}
+			matched = true
+		}
+	}
+	if matched {
+		s.signal()
+	}
+}
+
+func (s *Subscription) signal() {
+	select {
+	case s.c <- struct{}{}:
+	default:
+	}
+}
+
+// C is signaled when Next has topics
+func (s *Subscription) C() <-chan struct{} {
+	return s.c
+}
+
+// Next takes the changed topics in the order of filter and the event id of them
+func (s *Subscription) Next() (eventID string, topics []Topic) {
+	s.b.lock.Lock()
+	defer s.b.lock.Unlock()
+	for _, topic := range s.filter.Topics {
+		if _, ok := s.pending[topic]; ok {
+			topics = append(topics, topic)
+		}
+	}
+	s.pending = make(map[Topic]struct{})
+	return s.b.eventID(s.seq), topics
+}
+
+func (s *Subscription) Close() {
+	s.b.unsubscribe(s)
+}
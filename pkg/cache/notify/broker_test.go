@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	tntv1al "github.com/caicloud/clientset/pkg/apis/tenant/v1alpha1"
+)
+
+var testTopics = []Topic{TopicClusters, TopicApps, TopicEvents, TopicCI}
+
+// next waits for sub to be signaled and takes its topics
+func next(t *testing.T, sub *Subscription) (string, []Topic) {
+	select {
+	case <-sub.C():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for topics")
+	}
+	return sub.Next()
+}
+
+func expectNoSignal(t *testing.T, sub *Subscription) {
+	select {
+	case <-sub.C():
+		_, topics := sub.Next()
+		t.Fatalf("expect no topics, got %v", topics)
+	default:
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	for _, c := range []struct {
+		filter Filter
+		change Change
+		want   bool
+	}{
+		{Filter{Tenant: "a", Topics: testTopics}, Change{Topic: TopicApps, Tenant: "a"}, true},
+		{Filter{Tenant: "a", Topics: testTopics}, Change{Topic: TopicApps, Tenant: "b"}, false},
+		{Filter{Tenant: tntv1al.SystemTenant, Topics: testTopics}, Change{Topic: TopicApps, Tenant: "b"}, true},
+		{Filter{Tenant: "a", Topics: testTopics}, Change{Topic: TopicClusters}, true},
+		{Filter{Tenant: "a", Cluster: "c1", Topics: testTopics}, Change{Topic: TopicClusters, Cluster: "c2"}, false},
+		{Filter{Tenant: "a", Topics: testTopics}, Change{Topic: TopicClusters, Cluster: "c2"}, true},
+		{Filter{Tenant: "a", Topics: []Topic{TopicCI}}, Change{Topic: TopicApps, Tenant: "a"}, false},
+	} {
+		if got := c.filter.Match(c.change); got != c.want {
+			t.Errorf("%+v match %+v expect %v, got %v", c.filter, c.change, c.want, got)
+		}
+	}
+}
+
+func TestBrokerDebounce(t *testing.T) {
+	b := NewBroker(50 * time.Millisecond)
+	sub := b.Subscribe(Filter{Tenant: "a", Topics: testTopics}, "")
+	defer sub.Close()
+	// a new subscription gets all topics first
+	if _, topics := next(t, sub); !reflect.DeepEqual(topics, testTopics) {
+		t.Fatalf("expect all topics, got %v", topics)
+	}
+
+	b.Notify(Change{Topic: TopicCI})
+	b.Notify(Change{Topic: TopicApps, Tenant: "a"})
+	b.Notify(Change{Topic: TopicApps, Tenant: "b"})
+	b.Notify(Change{Topic: TopicCI})
+	expectNoSignal(t, sub)
+	id, topics := next(t, sub)
+	if !reflect.DeepEqual(topics, []Topic{TopicApps, TopicCI}) {
+		t.Fatalf("expect apps and ci in filter order, got %v", topics)
+	}
+	if id != b.eventID(1) {
+		t.Fatalf("expect changes flushed once as %s, got %s", b.eventID(1), id)
+	}
+
+	// changes of other tenants are flushed but not signaled
+	b.Notify(Change{Topic: TopicApps, Tenant: "b"})
+	time.Sleep(200 * time.Millisecond)
+	expectNoSignal(t, sub)
+}
+
+func TestBrokerResume(t *testing.T) {
+	b := NewBroker(time.Hour)
+	flush := func(changes ...Change) {
+		for _, change := range changes {
+			b.Notify(change)
+		}
+		b.flush()
+	}
+	flush(Change{Topic: TopicCI})
+	flush(Change{Topic: TopicApps, Tenant: "a"})
+	flush(Change{Topic: TopicClusters, Cluster: "c1"})
+	filter := Filter{Tenant: "a", Topics: testTopics}
+
+	// the changes after the last event id only
+	sub := b.Subscribe(filter, b.eventID(1))
+	if id, topics := next(t, sub); id != b.eventID(3) || !reflect.DeepEqual(topics, []Topic{TopicClusters, TopicApps}) {
+		t.Fatalf("expect clusters and apps at %s, got %v at %s", b.eventID(3), topics, id)
+	}
+	sub.Close()
+
+	// up to date, nothing to send
+	sub = b.Subscribe(filter, b.eventID(3))
+	expectNoSignal(t, sub)
+	sub.Close()
+
+	// ids of another broker, from the future or unknown get all topics
+	for _, id := range []string{"other-1", b.eventID(9), "bad"} {
+		sub = b.Subscribe(filter, id)
+		if _, topics := next(t, sub); !reflect.DeepEqual(topics, testTopics) {
+			t.Fatalf("expect all topics from %q, got %v", id, topics)
+		}
+		sub.Close()
+	}
+}
+
+func TestBrokerHistoryTrimmed(t *testing.T) {
+	b := NewBroker(time.Hour)
+	for i := 0; i < historySize+2; i++ {
+		b.Notify(Change{Topic: TopicCI})
+		b.flush()
+	}
+	if len(b.history) != historySize || b.history[0].seq != 3 {
+		t.Fatalf("expect the latest %d batches from 3, got %d from %d", historySize, len(b.history), b.history[0].seq)
+	}
+	for _, c := range []struct {
+		seq  uint64
+		want bool
+	}{
+		{1, false},
+		{2, true},
+		{historySize + 1, true},
+		{historySize + 2, true},
+	} {
+		if got := b.covers(c.seq); got != c.want {
+			t.Errorf("covers %d expect %v, got %v", c.seq, c.want, got)
+		}
+	}
+
+	// too old to resume, all topics are sent again
+	filter := Filter{Tenant: "a", Topics: []Topic{TopicApps, TopicCI}}
+	sub := b.Subscribe(filter, b.eventID(1))
+	defer sub.Close()
+	if _, topics := next(t, sub); !reflect.DeepEqual(topics, filter.Topics) {
+		t.Fatalf("expect all topics, got %v", topics)
+	}
+}
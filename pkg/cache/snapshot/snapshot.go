@@ -0,0 +1,194 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/caicloud/nirvana/log"
+)
+
+// FormatVersion is increased on every incompatible change of the file format
+// or of the saved data, files of other versions are ignored.
+const FormatVersion = 1
+
+const (
+	fileSuffix    = ".snapshot.json"
+	corruptSuffix = ".corrupt"
+)
+
+var (
+	ErrVarNotExist        = fmt.Errorf("snapshot not exist")
+	ErrVarVersionMismatch = fmt.Errorf("snapshot version mismatch")
+	ErrVarCorrupted       = fmt.Errorf("snapshot corrupted")
+)
+
+// Snapshotter is a cache which can be saved to and restored from a snapshot.
+type Snapshotter interface {
+	SnapshotName() string
+	// Snapshot returns the data to save, ok is false if there is no live data
+	Snapshot() (data interface{}, ok bool)
+	// Restore decodes the saved data by decode, which is stale until the cache is synced
+	Restore(savedAt time.Time, decode func(v interface{}) error) error
+}
+
+type envelope struct {
+	Version  int             `json:"version"`
+	Name     string          `json:"name"`
+	SavedAt  time.Time       `json:"savedAt"`
+	Checksum string          `json:"checksum"` // sha256 of data
+	Data     json.RawMessage `json:"data"`
+}
+
+// Store saves snapshots as files in a local directory
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) (*Store, error) {
+	if len(dir) == 0 {
+		return nil, fmt.Errorf("empty snapshot dir")
+	}
+	if e := os.MkdirAll(dir, 0755); e != nil {
+		return nil, e
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+fileSuffix)
+}
+
+// Save writes v atomically, a crash while saving keeps the last snapshot
+func (s *Store) Save(name string, v interface{}) error {
+	data, e := json.Marshal(v)
+	if e != nil {
+		return e
+	}
+	b, e := json.Marshal(&envelope{
+		Version:  FormatVersion,
+		Name:     name,
+		SavedAt:  time.Now(),
+		Checksum: checksum(data),
+		Data:     data,
+	})
+	if e != nil {
+		return e
+	}
+	f, e := ioutil.TempFile(s.dir, name+".tmp")
+	if e != nil {
+		return e
+	}
+	_, e = f.Write(b)
+	if e == nil {
+		e = f.Sync()
+	}
+	if ce := f.Close(); e == nil {
+		e = ce
+	}
+	if e == nil {
+		e = os.Rename(f.Name(), s.path(name))
+	}
+	if e != nil {
+		os.Remove(f.Name())
+	}
+	return e
+}
+
+// Load reads the snapshot of name into v. Corrupted files are renamed with
+// a .corrupt suffix so they are kept for debugging but never loaded again.
+func (s *Store) Load(name string, v interface{}) (savedAt time.Time, e error) {
+	fp := s.path(name)
+	b, e := ioutil.ReadFile(fp)
+	if os.IsNotExist(e) {
+		return savedAt, ErrVarNotExist
+	}
+	if e != nil {
+		return savedAt, e
+	}
+	env := new(envelope)
+	if e = json.Unmarshal(b, env); e != nil {
+		return savedAt, s.quarantine(fp, e)
+	}
+	if env.Version != FormatVersion {
+		return savedAt, fmt.Errorf("%v, got %d, want %d", ErrVarVersionMismatch, env.Version, FormatVersion)
+	}
+	if env.Name != name || env.Checksum != checksum(env.Data) {
+		return savedAt, s.quarantine(fp, fmt.Errorf("bad name or checksum"))
+	}
+	if e = json.Unmarshal(env.Data, v); e != nil {
+		return savedAt, s.quarantine(fp, e)
+	}
+	return env.SavedAt, nil
+}
+
+func (s *Store) quarantine(fp string, cause error) error {
+	if e := os.Rename(fp, fp+corruptSuffix); e != nil {
+		log.Errorf("rename corrupted snapshot %s failed, %v", fp, e)
+	}
+	return fmt.Errorf("%v, %s, %v", ErrVarCorrupted, fp, cause)
+}
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// RestoreAll restores every snapshotter, failures are logged and skipped
+func (s *Store) RestoreAll(ss []Snapshotter) {
+	for _, sn := range ss {
+		name := sn.SnapshotName()
+		var data json.RawMessage
+		savedAt, e := s.Load(name, &data)
+		if e == ErrVarNotExist {
+			log.Infof("no snapshot of %s", name)
+			continue
+		}
+		if e != nil {
+			log.Errorf("load snapshot of %s failed, %v", name, e)
+			continue
+		}
+		e = sn.Restore(savedAt, func(v interface{}) error {
+			return json.Unmarshal(data, v)
+		})
+		if e != nil {
+			log.Errorf("restore snapshot of %s failed, %v", name, e)
+			continue
+		}
+		log.Infof("restored snapshot of %s saved at %v", name, savedAt)
+	}
+}
+
+// SaveAll saves every snapshotter with live data
+func (s *Store) SaveAll(ss []Snapshotter) {
+	for _, sn := range ss {
+		name := sn.SnapshotName()
+		data, ok := sn.Snapshot()
+		if !ok {
+			continue
+		}
+		if e := s.Save(name, data); e != nil {
+			log.Errorf("save snapshot of %s failed, %v", name, e)
+		}
+	}
+}
+
+// Run saves all the snapshotters every interval and once more when stopped
+func (s *Store) Run(ss []Snapshotter, interval time.Duration, stopCh chan struct{}) {
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-stopCh:
+			s.SaveAll(ss)
+			return
+		case <-tk.C:
+			s.SaveAll(ss)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+type testData struct {
+	Items map[string]int `json:"items"`
+}
+
+func newTestStore(t *testing.T) *Store {
+	s, e := NewStore(t.TempDir())
+	if e != nil {
+		t.Fatalf("new store failed, %v", e)
+	}
+	if e = s.Save("test", &testData{Items: map[string]int{"a": 1}}); e != nil {
+		t.Fatalf("save failed, %v", e)
+	}
+	return s
+}
+
+// rewrite changes the saved envelope of name by f
+func rewrite(t *testing.T, s *Store, name string, f func(env *envelope)) {
+	b, e := ioutil.ReadFile(s.path(name))
+	if e != nil {
+		t.Fatalf("read failed, %v", e)
+	}
+	env := new(envelope)
+	if e = json.Unmarshal(b, env); e != nil {
+		t.Fatalf("unmarshal failed, %v", e)
+	}
+	f(env)
+	if b, e = json.Marshal(env); e != nil {
+		t.Fatalf("marshal failed, %v", e)
+	}
+	if e = ioutil.WriteFile(s.path(name), b, 0644); e != nil {
+		t.Fatalf("write failed, %v", e)
+	}
+}
+
+func assertQuarantined(t *testing.T, s *Store, name string, quarantined bool) {
+	if _, e := os.Stat(s.path(name) + corruptSuffix); (e == nil) != quarantined {
+		t.Fatalf("expect quarantined %v, stat got %v", quarantined, e)
+	}
+	if _, e := os.Stat(s.path(name)); (e == nil) == quarantined {
+		t.Fatalf("expect snapshot kept %v, stat got %v", !quarantined, e)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	s := newTestStore(t)
+	v := new(testData)
+	if _, e := s.Load("test", v); e != nil || v.Items["a"] != 1 {
+		t.Fatalf("load got %v, %v", v, e)
+	}
+	if _, e := s.Load("missing", v); e != ErrVarNotExist {
+		t.Fatalf("load missing got %v", e)
+	}
+}
+
+func TestLoadVersionMismatch(t *testing.T) {
+	s := newTestStore(t)
+	rewrite(t, s, "test", func(env *envelope) { env.Version = FormatVersion + 1 })
+	_, e := s.Load("test", new(testData))
+	if e == nil || !strings.HasPrefix(e.Error(), ErrVarVersionMismatch.Error()) {
+		t.Fatalf("expect version mismatch, got %v", e)
+	}
+	// kept for the version which can read it
+	assertQuarantined(t, s, "test", false)
+}
+
+func TestLoadChecksumMismatch(t *testing.T) {
+	s := newTestStore(t)
+	rewrite(t, s, "test", func(env *envelope) { env.Data = json.RawMessage(`{"items":{"a":2}}`) })
+	_, e := s.Load("test", new(testData))
+	if e == nil || !strings.HasPrefix(e.Error(), ErrVarCorrupted.Error()) {
+		t.Fatalf("expect corrupted, got %v", e)
+	}
+	assertQuarantined(t, s, "test", true)
+	if _, e = s.Load("test", new(testData)); e != ErrVarNotExist {
+		t.Fatalf("load after quarantine got %v", e)
+	}
+}
+
+func TestLoadTruncated(t *testing.T) {
+	s := newTestStore(t)
+	b, e := ioutil.ReadFile(s.path("test"))
+	if e != nil {
+		t.Fatalf("read failed, %v", e)
+	}
+	if e = ioutil.WriteFile(s.path("test"), b[:len(b)/2], 0644); e != nil {
+		t.Fatalf("write failed, %v", e)
+	}
+	_, e = s.Load("test", new(testData))
+	if e == nil || !strings.HasPrefix(e.Error(), ErrVarCorrupted.Error()) {
+		t.Fatalf("expect corrupted, got %v", e)
+	}
+	assertQuarantined(t, s, "test", true)
+}
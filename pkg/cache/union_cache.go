@@ -2,9 +2,12 @@ package cache
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/caicloud/dashboard-admin/pkg/cache/api"
 	"github.com/caicloud/dashboard-admin/pkg/cache/crd"
+	"github.com/caicloud/dashboard-admin/pkg/cache/notify"
+	"github.com/caicloud/dashboard-admin/pkg/cache/snapshot"
 	"github.com/caicloud/dashboard-admin/pkg/config"
 	"github.com/caicloud/dashboard-admin/pkg/errors"
 	"github.com/caicloud/dashboard-admin/pkg/kubernetes"
@@ -13,6 +16,10 @@ import (
 type Cache struct {
 	*crd.ClusterResourcesCache
 	*api.Cache
+
+	cfg      config.Config
+	snapshot *snapshot.Store
+	broker   *notify.Broker
 }
 
 func NewCache(cfg *config.Config) (*Cache, error) {
@@ -28,23 +35,81 @@ func NewCache(cfg *config.Config) (*Cache, error) {
 		return nil, fmt.Errorf("NewClientFromFlags failed, %v", e)
 	}
 
-	cc, e := crd.NewClusterResourcesCache(kc, crd.GetDefaultConfig())
+	fallbackPolicy, e := crd.ParseFallbackPolicy(cfg.FallbackPolicy)
 	if e != nil {
 		return nil, e
 	}
+
+	cacheSet := crd.NewDefaultCacheSetConfig()
+	if len(cfg.CacheSetConfigPath) > 0 {
+		cacheSet, e = crd.ReadCacheSetConfigFile(cfg.CacheSetConfigPath)
+		if e != nil {
+			return nil, fmt.Errorf("ReadCacheSetConfigFile %s failed, %v", cfg.CacheSetConfigPath, e)
+		}
+	}
+	cc, e := crd.NewClusterResourcesCache(kc, cacheSet)
+	if e != nil {
+		return nil, e
+	}
+	cc.SetFallbackConfig(crd.FallbackConfig{
+		Policy:  fallbackPolicy,
+		QPS:     cfg.FallbackQPS,
+		Burst:   cfg.FallbackBurst,
+		Timeout: time.Duration(cfg.FallbackTimeoutSecond) * time.Second,
+	})
+	cc.SetRecountInterval(time.Duration(cfg.RecountSecond) * time.Second)
+	if cfg.LazyClusterCache {
+		cc.EnableLazyMode(crd.LazyConfig{
+			SyncTimeout: time.Duration(cfg.LazySyncTimeoutSecond) * time.Second,
+			IdleTimeout: time.Duration(cfg.LazyIdleSecond) * time.Second,
+		})
+	}
 	ac, e := api.NewCache(cfg)
 	if e != nil {
 		return nil, e
 	}
 
-	return &Cache{
+	c := &Cache{
 		ClusterResourcesCache: cc,
-		Cache: ac,
-	}, nil
+		Cache:                 ac,
+		cfg:                   *cfg,
+		broker:                notify.NewBroker(time.Duration(cfg.WatchDebounceSecond) * time.Second),
+	}
+	cc.SetNotifier(c.broker)
+	ac.SetNotifier(c.broker)
+	if len(cfg.SnapshotDir) > 0 {
+		c.snapshot, e = snapshot.NewStore(cfg.SnapshotDir)
+		if e != nil {
+			return nil, fmt.Errorf("NewStore %s failed, %v", cfg.SnapshotDir, e)
+		}
+		c.snapshot.RestoreAll(c.Snapshotters())
+	}
+	return c, nil
+}
+
+// Subscribe watches the changes of filter from lastEventID, see notify.Broker
+func (c *Cache) Subscribe(filter notify.Filter, lastEventID string) *notify.Subscription {
+	return c.broker.Subscribe(filter, lastEventID)
+}
+
+// WatchHeartbeat is the interval of keepalive messages of watch streams
+func (c *Cache) WatchHeartbeat() time.Duration {
+	return time.Duration(c.cfg.WatchHeartbeatSecond) * time.Second
+}
+
+func (c *Cache) Snapshotters() []snapshot.Snapshotter {
+	return append([]snapshot.Snapshotter{c.ClusterResourcesCache}, c.Cache.Snapshotters()...)
 }
 
 func (c *Cache) Run(stopCh chan struct{}) {
 	go c.ClusterResourcesCache.Run(stopCh)
 	go c.Cache.Run(stopCh)
+	if len(c.cfg.CacheSetConfigPath) > 0 {
+		go crd.RunCacheSetReloader(c.ClusterResourcesCache, c.cfg.CacheSetConfigPath,
+			time.Duration(c.cfg.RefreshSecond)*time.Second, stopCh)
+	}
+	if c.snapshot != nil {
+		go c.snapshot.Run(c.Snapshotters(), time.Duration(c.cfg.SnapshotIntervalSecond)*time.Second, stopCh)
+	}
 	<-stopCh
 }
@@ -3,6 +3,8 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/caicloud/dashboard-admin/pkg/constants"
 )
@@ -16,18 +18,105 @@ type Config struct {
 	TimeoutSecond int
 	RefreshSecond int
 
-	// hosts
+	// upstream refreshers
+	CauthRefreshSecond          int `desc:"seconds between cauth refreshes, 0 means RefreshSecond"`
+	DevOpAdminRefreshSecond     int `desc:"seconds between devops admin refreshes, 0 means RefreshSecond"`
+	CargoAdminRefreshSecond     int `desc:"seconds between cargo admin refreshes, 0 means RefreshSecond"`
+	RefreshInitialBackoffSecond int `desc:"seconds before the first retry of a failing upstream, at most its refresh interval"`
+	RefreshMaxBackoffSecond     int `desc:"max seconds between retries of a failing upstream, doubled from the initial backoff"`
+	RefreshMaxStalenessSecond   int `desc:"upstream data not refreshed in it is reported stale, 0 means only data never refreshed"`
+	DevOpAdminConcurrency       int `desc:"max concurrent pipeline lists of devops admin workspaces"`
+	CargoAdminConcurrency       int `desc:"max concurrent repository lists of cargo admin projects"`
+	CargoAdminRepositorySecond  int `desc:"min seconds between repository lists of a cargo admin project, 0 means every refresh"`
+	CauthPageSize               int `desc:"users, teams, tenants or roles per cauth list request"`
+
+	// on-demand refresh
+	RefreshTriggerQPS   float64 `desc:"on-demand upstream refreshes per second of a user, 0 means no limit"`
+	RefreshTriggerBurst int     `desc:"on-demand upstream refresh burst of a user"`
+
+	// upstream circuit breakers
+	BreakerFailureThreshold int `desc:"consecutive failures opening the breaker of an upstream, 0 means never"`
+	BreakerOpenSecond       int `desc:"seconds an open breaker fails fast before probing its upstream"`
+	BreakerSuccessThreshold int `desc:"consecutive successful probes closing the breaker of an upstream"`
+
+	CacheSetConfigPath string `desc:"json file choosing resource caches globally and per cluster, reloaded on change"`
+
+	// lazy cluster cache
+	LazyClusterCache      bool `desc:"start cluster caches on the first request and stop them when idle"`
+	LazySyncTimeoutSecond int  `desc:"max seconds for a request to wait for lazy cluster caches to sync"`
+	LazyIdleSecond        int  `desc:"seconds after which unrequested lazy cluster caches are stopped, 0 means never"`
+
+	// live fallback of cluster caches, per cluster and resource
+	FallbackPolicy        string  `desc:"when cache misses read the cluster: never, unsynced or always"`
+	FallbackQPS           float64 `desc:"live fallback requests per second, 0 means no limit"`
+	FallbackBurst         int     `desc:"live fallback request burst"`
+	FallbackTimeoutSecond int     `desc:"max seconds of a live fallback request"`
+
+	// summary counters
+	RecountSecond int `desc:"seconds between full recounts correcting the summary counters, 0 means never"`
+
+	// live push
+	WatchDebounceSecond  int `desc:"seconds to collect changes before pushing them to watchers"`
+	WatchHeartbeatSecond int `desc:"seconds between keepalive comments of idle watch streams"`
+
+	// warm start
+	SnapshotDir            string `desc:"local dir of cache snapshots served as stale on start, empty means disabled"`
+	SnapshotIntervalSecond int    `desc:"seconds between cache snapshots"`
+
+	// hosts, host:port means http, or a url with scheme and path prefix
 	CauthHost      string
 	DevOpAdminHost string
 	CargoAdminHost string
+
+	// upstream tls and auth, shared tls files and per upstream credential files
+	UpstreamCAFile          string `desc:"pem CA bundle verifying the upstreams, empty means system roots"`
+	UpstreamCertFile        string `desc:"pem client certificate for the upstreams"`
+	UpstreamKeyFile         string `desc:"pem client key for the upstreams"`
+	CauthTokenFile          string `desc:"file of the bearer token for cauth"`
+	CauthBasicAuthFile      string `desc:"file of username:password for cauth"`
+	DevOpAdminTokenFile     string `desc:"file of the bearer token for devops admin"`
+	DevOpAdminBasicAuthFile string `desc:"file of username:password for devops admin"`
+	CargoAdminTokenFile     string `desc:"file of the bearer token for cargo admin"`
+	CargoAdminBasicAuthFile string `desc:"file of username:password for cargo admin"`
 }
 
 func NewDefaultConfig() *Config {
 	return &Config{
-		KubeHost:       constants.DefaultKubeHost,
-		KubeConfig:     constants.DefaultKubeConfig,
-		TimeoutSecond:  constants.DefaultTimeoutSecond,
-		RefreshSecond:  constants.DefaultRefreshSecond,
+		KubeHost:      constants.DefaultKubeHost,
+		KubeConfig:    constants.DefaultKubeConfig,
+		TimeoutSecond: constants.DefaultTimeoutSecond,
+		RefreshSecond: constants.DefaultRefreshSecond,
+
+		RefreshInitialBackoffSecond: constants.DefaultRefreshInitialBackoffSecond,
+		RefreshMaxBackoffSecond:     constants.DefaultRefreshMaxBackoffSecond,
+		RefreshMaxStalenessSecond:   constants.DefaultRefreshMaxStalenessSecond,
+		DevOpAdminConcurrency:       constants.DefaultDevOpAdminConcurrency,
+		CargoAdminConcurrency:       constants.DefaultCargoAdminConcurrency,
+		CargoAdminRepositorySecond:  constants.DefaultCargoAdminRepositorySecond,
+		CauthPageSize:               constants.DefaultCauthPageSize,
+
+		RefreshTriggerQPS:   constants.DefaultRefreshTriggerQPS,
+		RefreshTriggerBurst: constants.DefaultRefreshTriggerBurst,
+
+		BreakerFailureThreshold: constants.DefaultBreakerFailureThreshold,
+		BreakerOpenSecond:       constants.DefaultBreakerOpenSecond,
+		BreakerSuccessThreshold: constants.DefaultBreakerSuccessThreshold,
+
+		LazySyncTimeoutSecond: constants.DefaultLazySyncTimeoutSecond,
+		LazyIdleSecond:        constants.DefaultLazyIdleSecond,
+
+		FallbackPolicy:        constants.DefaultFallbackPolicy,
+		FallbackQPS:           constants.DefaultFallbackQPS,
+		FallbackBurst:         constants.DefaultFallbackBurst,
+		FallbackTimeoutSecond: constants.DefaultFallbackTimeoutSecond,
+
+		RecountSecond: constants.DefaultRecountSecond,
+
+		WatchDebounceSecond:  constants.DefaultWatchDebounceSecond,
+		WatchHeartbeatSecond: constants.DefaultWatchHeartbeatSecond,
+
+		SnapshotIntervalSecond: constants.DefaultSnapshotIntervalSecond,
+
 		CauthHost:      constants.DefaultCauthHost,
 		DevOpAdminHost: constants.DefaultDevOpAdminHost,
 		CargoAdminHost: constants.DefaultCargoAdminHost,
@@ -41,14 +130,93 @@ func (c *Config) Validate() error {
 	if c.RefreshSecond < 1 {
 		return fmt.Errorf("illegal refresh seconds %d", c.RefreshSecond)
 	}
-	if len(c.CauthHost) == 0 {
-		return fmt.Errorf("empty cauth host")
+	if c.CauthRefreshSecond < 0 || c.DevOpAdminRefreshSecond < 0 || c.CargoAdminRefreshSecond < 0 {
+		return fmt.Errorf("illegal upstream refresh seconds %d, %d, %d",
+			c.CauthRefreshSecond, c.DevOpAdminRefreshSecond, c.CargoAdminRefreshSecond)
+	}
+	if c.RefreshInitialBackoffSecond < 1 {
+		return fmt.Errorf("illegal refresh initial backoff seconds %d", c.RefreshInitialBackoffSecond)
+	}
+	if c.RefreshMaxBackoffSecond < 1 {
+		return fmt.Errorf("illegal refresh max backoff seconds %d", c.RefreshMaxBackoffSecond)
+	}
+	if c.RefreshMaxStalenessSecond < 0 {
+		return fmt.Errorf("illegal refresh max staleness seconds %d", c.RefreshMaxStalenessSecond)
+	}
+	if c.DevOpAdminConcurrency < 1 {
+		return fmt.Errorf("illegal devops admin concurrency %d", c.DevOpAdminConcurrency)
+	}
+	if c.CargoAdminConcurrency < 1 {
+		return fmt.Errorf("illegal cargo admin concurrency %d", c.CargoAdminConcurrency)
+	}
+	if c.CargoAdminRepositorySecond < 0 {
+		return fmt.Errorf("illegal cargo admin repository seconds %d", c.CargoAdminRepositorySecond)
+	}
+	if c.CauthPageSize < 1 {
+		return fmt.Errorf("illegal cauth page size %d", c.CauthPageSize)
 	}
-	if len(c.DevOpAdminHost) == 0 {
-		return fmt.Errorf("empty devop admin host")
+	if c.RefreshTriggerQPS < 0 {
+		return fmt.Errorf("illegal refresh trigger qps %v", c.RefreshTriggerQPS)
 	}
-	if len(c.CargoAdminHost) == 0 {
-		return fmt.Errorf("empty cargo admin host")
+	if c.RefreshTriggerBurst < 1 {
+		return fmt.Errorf("illegal refresh trigger burst %d", c.RefreshTriggerBurst)
+	}
+	if c.BreakerFailureThreshold < 0 {
+		return fmt.Errorf("illegal breaker failure threshold %d", c.BreakerFailureThreshold)
+	}
+	if c.BreakerOpenSecond < 1 {
+		return fmt.Errorf("illegal breaker open seconds %d", c.BreakerOpenSecond)
+	}
+	if c.BreakerSuccessThreshold < 1 {
+		return fmt.Errorf("illegal breaker success threshold %d", c.BreakerSuccessThreshold)
+	}
+	if c.LazySyncTimeoutSecond < 0 {
+		return fmt.Errorf("illegal lazy sync timeout seconds %d", c.LazySyncTimeoutSecond)
+	}
+	if c.LazyIdleSecond < 0 {
+		return fmt.Errorf("illegal lazy idle seconds %d", c.LazyIdleSecond)
+	}
+	if c.FallbackQPS < 0 {
+		return fmt.Errorf("illegal fallback qps %v", c.FallbackQPS)
+	}
+	if c.FallbackBurst < 0 {
+		return fmt.Errorf("illegal fallback burst %d", c.FallbackBurst)
+	}
+	if c.FallbackTimeoutSecond < 1 {
+		return fmt.Errorf("illegal fallback timeout seconds %d", c.FallbackTimeoutSecond)
+	}
+	if c.RecountSecond < 0 {
+		return fmt.Errorf("illegal recount seconds %d", c.RecountSecond)
+	}
+	if c.WatchDebounceSecond < 1 {
+		return fmt.Errorf("illegal watch debounce seconds %d", c.WatchDebounceSecond)
+	}
+	if c.WatchHeartbeatSecond < 1 {
+		return fmt.Errorf("illegal watch heartbeat seconds %d", c.WatchHeartbeatSecond)
+	}
+	if len(c.SnapshotDir) > 0 && c.SnapshotIntervalSecond < 1 {
+		return fmt.Errorf("illegal snapshot interval seconds %d", c.SnapshotIntervalSecond)
+	}
+	if e := validateHost("cauth", c.CauthHost); e != nil {
+		return e
+	}
+	if e := validateHost("devop admin", c.DevOpAdminHost); e != nil {
+		return e
+	}
+	if e := validateHost("cargo admin", c.CargoAdminHost); e != nil {
+		return e
+	}
+	if (len(c.UpstreamCertFile) > 0) != (len(c.UpstreamKeyFile) > 0) {
+		return fmt.Errorf("upstream cert and key files must be set together")
+	}
+	if len(c.CauthTokenFile) > 0 && len(c.CauthBasicAuthFile) > 0 {
+		return fmt.Errorf("both token and basic auth files of cauth")
+	}
+	if len(c.DevOpAdminTokenFile) > 0 && len(c.DevOpAdminBasicAuthFile) > 0 {
+		return fmt.Errorf("both token and basic auth files of devop admin")
+	}
+	if len(c.CargoAdminTokenFile) > 0 && len(c.CargoAdminBasicAuthFile) > 0 {
+		return fmt.Errorf("both token and basic auth files of cargo admin")
 	}
 	return nil
 }
@@ -56,3 +224,21 @@ func (c *Config) String() string {
 	b, _ := json.MarshalIndent(c, "", "  ")
 	return string(b)
 }
+
+// validateHost checks a host:port or a http(s) url
+func validateHost(name, host string) error {
+	if len(host) == 0 {
+		return fmt.Errorf("empty %s host", name)
+	}
+	if !strings.Contains(host, "://") {
+		return nil
+	}
+	u, e := url.Parse(host)
+	if e != nil {
+		return fmt.Errorf("illegal %s host %s, %v", name, host, e)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return fmt.Errorf("illegal %s host %s, want http or https url", name, host)
+	}
+	return nil
+}
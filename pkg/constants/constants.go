@@ -21,6 +21,22 @@ const (
 	ParameterRequestBody = "req"
 	ParameterXUser       = "X-User"
 	ParameterXTenant     = "X-Tenant"
+
+	ParameterCacheName = "name"
+	ParameterRegistry  = "registry"
+
+	ParameterSort  = "sort"
+	ParameterReady = "ready"
+	ParameterRole  = "role"
+
+	ParameterPhase    = "phase"
+	ParameterProvider = "provider"
+	ParameterTag      = "tag"
+
+	ParameterTop = "top"
+
+	ParameterLastEventID      = "Last-Event-ID"
+	ParameterLastEventIDQuery = "lastEventId"
 )
 
 const (
@@ -31,6 +47,36 @@ const (
 	DefaultTimeoutSecond = 3
 	DefaultRefreshSecond = 30
 
+	DefaultRefreshInitialBackoffSecond = 5
+	DefaultRefreshMaxBackoffSecond     = 300
+	DefaultRefreshMaxStalenessSecond   = 300
+	DefaultDevOpAdminConcurrency       = 8
+	DefaultCargoAdminConcurrency       = 8
+	DefaultCargoAdminRepositorySecond  = 300
+	DefaultCauthPageSize               = 100
+
+	DefaultRefreshTriggerQPS   = 0.2
+	DefaultRefreshTriggerBurst = 2
+
+	DefaultBreakerFailureThreshold = 5
+	DefaultBreakerOpenSecond       = 30
+	DefaultBreakerSuccessThreshold = 1
+
+	DefaultLazySyncTimeoutSecond = 5
+	DefaultLazyIdleSecond        = 600
+
+	DefaultFallbackPolicy        = "unsynced"
+	DefaultFallbackQPS           = 1
+	DefaultFallbackBurst         = 5
+	DefaultFallbackTimeoutSecond = 10
+
+	DefaultRecountSecond = 300
+
+	DefaultWatchDebounceSecond  = 1
+	DefaultWatchHeartbeatSecond = 30
+
+	DefaultSnapshotIntervalSecond = 60
+
 	DefaultCauthHost      = "dex-cauth:8080"
 	DefaultDevOpAdminHost = "devops-admin:7088"
 	DefaultCargoAdminHost = "cargo-admin:8080"
@@ -18,9 +18,18 @@ const (
 	ErrorReasonObjectNotFound      = ReasonGroupStorage + "ObjectNotFound"
 	ErrorReasonObjectConflict      = ReasonGroupStorage + "Conflict"
 	ErrorReasonMissParameter       = ReasonGroupStorage + "MissParameter"
+	ErrorReasonBadParameter        = ReasonGroupStorage + "BadParameter"
 	ErrorReasonClusterNotFound     = ReasonGroupStorage + "ClusterNotFound"
 	ErrorReasonClusterNotReady     = ReasonGroupStorage + "ClusterNotReady"
+	ErrorReasonClusterNotSynced    = ReasonGroupStorage + "ClusterNotSynced"
+	ErrorReasonCacheDisabled       = ReasonGroupStorage + "CacheDisabled"
+	// upstream
+	ErrorReasonUpstreamUnavailable = ReasonGroupStorage + "UpstreamUnavailable"
+	ErrorReasonUpstreamBadResponse = ReasonGroupStorage + "UpstreamBadResponse"
+	ErrorReasonUpstreamNotSynced   = ReasonGroupStorage + "UpstreamNotSynced"
 	// other error
+	ErrorReasonForbidden           = ReasonGroupStorage + "Forbidden"
+	ErrorReasonTooManyRequests     = ReasonGroupStorage + "TooManyRequests"
 	ErrorReasonAuthFailed          = ReasonGroupStorage + "AuthFailed"
 	ErrorReasonInternalServerError = ReasonGroupStorage + "InternalServerError"
 )
@@ -96,6 +105,13 @@ func (fe *FormatError) SetErrorMissParameter(name string) *FormatError {
 	return fe
 }
 
+func (fe *FormatError) SetErrorBadParameter(name, value string) *FormatError {
+	fe.ApiError.Message = fmt.Sprintf("bad value %q of parameter %s", value, name)
+	fe.Reason = ErrorReasonBadParameter
+	fe.HttpCode = http.StatusBadRequest
+	return fe
+}
+
 // cluster
 
 func (fe *FormatError) SetErrorClusterNotReady(cluster, status string) *FormatError {
@@ -105,6 +121,63 @@ func (fe *FormatError) SetErrorClusterNotReady(cluster, status string) *FormatEr
 	return fe
 }
 
+// SetErrorClusterNotSynced is for the caches of a cluster started on demand
+// but not synced in time
+func (fe *FormatError) SetErrorClusterNotSynced(cluster string) *FormatError {
+	fe.ApiError.Message = fmt.Sprintf("caches of cluster %s are not synced yet", cluster)
+	fe.Reason = ErrorReasonClusterNotSynced
+	fe.HttpCode = http.StatusServiceUnavailable
+	return fe
+}
+
+// SetErrorCacheDisabled is for the caches not enabled in the cache set of the cluster
+func (fe *FormatError) SetErrorCacheDisabled(cluster, name string) *FormatError {
+	fe.ApiError.Message = fmt.Sprintf("cache %s of cluster %s is disabled", name, cluster)
+	fe.Reason = ErrorReasonCacheDisabled
+	fe.HttpCode = http.StatusServiceUnavailable
+	return fe
+}
+
+// upstream
+
+// UpstreamErrorData is the data of upstream errors, Reason, Message and
+// Details are the ones in the error body of the upstream if any
+type UpstreamErrorData struct {
+	Service  string `json:"service"`
+	HttpCode int    `json:"httpCode,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Details  string `json:"details,omitempty"`
+}
+
+// SetErrorUpstreamUnavailable is for upstreams not reachable or answering 502/503/504
+func (fe *FormatError) SetErrorUpstreamUnavailable(data *UpstreamErrorData, e error) *FormatError {
+	fe.ApiError.Message = fmt.Sprintf("upstream %s is unavailable", data.Service)
+	fe.Reason = ErrorReasonUpstreamUnavailable
+	fe.Data = data
+	fe.HttpCode = http.StatusServiceUnavailable
+	fe.SetRawError(e)
+	return fe
+}
+
+// SetErrorUpstreamBadResponse is for unexpected codes or bodies of upstreams
+func (fe *FormatError) SetErrorUpstreamBadResponse(data *UpstreamErrorData, e error) *FormatError {
+	fe.ApiError.Message = fmt.Sprintf("upstream %s returned a bad response", data.Service)
+	fe.Reason = ErrorReasonUpstreamBadResponse
+	fe.Data = data
+	fe.HttpCode = http.StatusBadGateway
+	fe.SetRawError(e)
+	return fe
+}
+
+// SetErrorUpstreamNotSynced is for the data of upstreams not refreshed since start
+func (fe *FormatError) SetErrorUpstreamNotSynced(service string) *FormatError {
+	fe.ApiError.Message = fmt.Sprintf("data of upstream %s is not synced yet", service)
+	fe.Reason = ErrorReasonUpstreamNotSynced
+	fe.HttpCode = http.StatusServiceUnavailable
+	return fe
+}
+
 // other error
 
 func (fe *FormatError) SetErrorAuthFailed(e error) *FormatError {
@@ -115,6 +188,20 @@ func (fe *FormatError) SetErrorAuthFailed(e error) *FormatError {
 	return fe
 }
 
+func (fe *FormatError) SetErrorForbidden(xTenant, xUser, action string) *FormatError {
+	fe.ApiError.Message = fmt.Sprintf("[%s:%s] is not allowed to %s", xTenant, xUser, action)
+	fe.Reason = ErrorReasonForbidden
+	fe.HttpCode = http.StatusForbidden
+	return fe
+}
+
+func (fe *FormatError) SetErrorTooManyRequests(xUser, action string) *FormatError {
+	fe.ApiError.Message = fmt.Sprintf("too many requests of %s to %s", xUser, action)
+	fe.Reason = ErrorReasonTooManyRequests
+	fe.HttpCode = http.StatusTooManyRequests
+	return fe
+}
+
 func (fe *FormatError) SetErrorInternalServerError(e error) *FormatError {
 	fe.ApiError.Message = fmt.Sprintf("internal server error")
 	fe.Reason = ErrorReasonInternalServerError